@@ -0,0 +1,175 @@
+// Package auth handles user accounts: registering users, checking their
+// passwords, and keeping track of the logged-in user in the session.
+//
+// Once the Middleware has run, the current user is available from the
+// context:
+//
+//     if user := auth.FromContext(ctx); user != nil {
+//         log.Printf("info: request from user_id=%d", user.ID)
+//     }
+//
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"goji.io"
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+	"github.com/andrew-d/go-webapp-skeleton/session"
+	"github.com/andrew-d/go-webapp-skeleton/validate"
+)
+
+// Limits on the length of a password.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 256
+)
+
+// ErrInvalidCredentials is returned by Authenticate if there is no user with
+// the given email address, or the password is wrong.  The two cases are
+// deliberately not distinguished.
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// Session key that holds the ID of the logged-in user.
+const sessionUserKey = "user_id"
+
+// Register creates a new user with the given email address and password.
+// Problems with either are returned as validate.Errors, including an email
+// address that is already registered.
+func Register(ctx context.Context, email, password string) (*model.User, error) {
+	var errs validate.Errors
+
+	user := &model.User{Email: email}
+	if err := validate.Struct(user); err != nil {
+		errs = append(errs, err.(validate.Errors)...)
+	}
+	switch n := utf8.RuneCountInString(password); {
+	case n < MinPasswordLength:
+		errs.Add("password", "must be at least %d characters long", MinPasswordLength)
+	case n > MaxPasswordLength:
+		errs.Add("password", "must be at most %d characters long", MaxPasswordLength)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = hash
+
+	err = datastore.CreateUser(ctx, user)
+	if err == datastore.ErrConflict {
+		errs.Add("email", "is already registered")
+		return nil, errs
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Authenticate returns the user with the given email address and password,
+// or ErrInvalidCredentials.
+func Authenticate(ctx context.Context, email, password string) (*model.User, error) {
+	if len(password) > MaxPasswordLength*utf8.UTFMax {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := datastore.GetUserByEmail(ctx, model.NormalizeEmail(email))
+	if err == datastore.ErrNotFound {
+		// Check the password anyway, so that the response time doesn't
+		// reveal whether the email address is registered.
+		CheckPassword(dummyHash(), password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch err := CheckPassword(user.PasswordHash, password); err {
+	case nil:
+		return user, nil
+	case ErrPasswordMismatch:
+		return nil, ErrInvalidCredentials
+	default:
+		log.Printf("error: invalid password hash user_id=%d err=%q", user.ID, err)
+		return nil, ErrInvalidCredentials
+	}
+}
+
+var (
+	dummyHashOnce  sync.Once
+	dummyHashValue string
+)
+
+// dummyHash returns a password hash that doesn't belong to any user.
+func dummyHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHashValue, _ = HashPassword("")
+	})
+	return dummyHashValue
+}
+
+// Login records the given user as logged in for the rest of the session.
+// The session is given a new ID, to prevent session fixation.
+func Login(ctx context.Context, user *model.User) {
+	sess := session.FromContext(ctx)
+	sess.Regenerate()
+	sess.Set(sessionUserKey, strconv.FormatInt(user.ID, 10))
+}
+
+// Logout ends the current session.
+func Logout(ctx context.Context) {
+	session.FromContext(ctx).Destroy()
+}
+
+// Middleware loads the logged-in user from the session, and puts it in the
+// context.  It must run after the session middleware.
+func Middleware(h goji.Handler) goji.Handler {
+	fn := func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		sess := session.FromContext(ctx)
+
+		if idStr := sess.Get(sessionUserKey); idStr != "" {
+			id, _ := strconv.ParseInt(idStr, 10, 64)
+			user, err := datastore.GetUser(ctx, id)
+			switch err {
+			case nil:
+				ctx = NewContext(ctx, user)
+			case datastore.ErrNotFound:
+				// The user was deleted; log them out.
+				sess.Delete(sessionUserKey)
+			default:
+				log.Printf("error: could not load user user_id=%q err=%q", idStr, err)
+			}
+		}
+
+		h.ServeHTTPC(ctx, w, r)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+type private struct{}
+
+var contextKey private
+
+func NewContext(parent context.Context, user *model.User) context.Context {
+	return context.WithValue(parent, contextKey, user)
+}
+
+// FromContext returns the logged-in user, or nil if the request isn't from a
+// logged-in user.
+func FromContext(c context.Context) *model.User {
+	user, _ := c.Value(contextKey).(*model.User)
+	return user
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Passwords are hashed with bcrypt.  bcrypt only uses the first 72 bytes of
+// a password, which is less than MaxPasswordLength, so passwords are first
+// hashed with SHA-256 (and base64-encoded, since bcrypt also stops at a NUL
+// byte).  The cost is stored with each hash, so it can be raised without
+// invalidating existing passwords.
+const hashCost = 12
+
+// ErrPasswordMismatch is returned by CheckPassword if the password does not
+// match the hash.
+var ErrPasswordMismatch = errors.New("password does not match")
+
+// HashPassword returns a salted hash of the given password, suitable for
+// storing in the database.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(prehash(password), hashCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword returns nil if the password matches the given hash, and
+// ErrPasswordMismatch if it doesn't.  Any other error means that the hash is
+// malformed.
+func CheckPassword(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), prehash(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+// prehash returns the password as it's given to bcrypt.
+func prehash(password string) []byte {
+	sum := sha256.Sum256([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(sum[:]))
+}
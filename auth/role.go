@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+// Permissions that can be required by handlers.
+const (
+	PermPeopleWrite  = "people:write"
+	PermPeopleDelete = "people:delete"
+)
+
+// Roles maps the name of each role to the permissions it grants.  Users are
+// assigned roles (see GrantRole), never individual permissions.
+var Roles = map[string][]string{
+	"admin": {
+		PermPeopleWrite,
+		PermPeopleDelete,
+	},
+	"editor": {
+		PermPeopleWrite,
+	},
+}
+
+// RoleNames returns the names of all roles, in alphabetical order.
+func RoleNames() []string {
+	var names []string
+	for name := range Roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasPermission returns whether the given user has a role that grants the
+// given permission.  A nil user has no permissions.
+func HasPermission(ctx context.Context, user *model.User, perm string) (bool, error) {
+	if user == nil {
+		return false, nil
+	}
+
+	roles, err := datastore.ListUserRoles(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, role := range roles {
+		for _, p := range Roles[role] {
+			if p == perm {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// GrantRole assigns the given role to the user.
+func GrantRole(ctx context.Context, user *model.User, role string) error {
+	if _, ok := Roles[role]; !ok {
+		return fmt.Errorf("unknown role %q", role)
+	}
+	return datastore.AddUserRole(ctx, user.ID, role)
+}
+
+// RevokeRole removes the given role from the user.
+func RevokeRole(ctx context.Context, user *model.User, role string) error {
+	return datastore.RemoveUserRole(ctx, user.ID, role)
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+	"github.com/andrew-d/go-webapp-skeleton/validate"
+)
+
+// ErrInvalidToken is returned by AuthenticateToken if the API token doesn't
+// exist or has been revoked.
+var ErrInvalidToken = errors.New("invalid API token")
+
+// Length of an API token, in random bytes.
+const tokenLength = 32
+
+// NewToken creates a new API token for the given user.  The token itself is
+// returned, and must be shown to the user now since only its hash is stored.
+// Problems with the name are returned as validate.Errors.
+func NewToken(ctx context.Context, user *model.User, name string) (string, *model.Token, error) {
+	token := &model.Token{
+		UserID:    user.ID,
+		Name:      name,
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := validate.Struct(token); err != nil {
+		return "", nil, err
+	}
+
+	buf := make([]byte, tokenLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+	token.Hash = HashToken(raw)
+
+	if err := datastore.CreateToken(ctx, token); err != nil {
+		return "", nil, err
+	}
+	return raw, token, nil
+}
+
+// HashToken returns the hash of an API token that is stored in the database.
+// Tokens are long and random, so unlike passwords they don't need a slow,
+// salted hash.
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuthenticateToken returns the API token with the given value, and the user
+// that it belongs to, or ErrInvalidToken.
+func AuthenticateToken(ctx context.Context, raw string) (*model.User, *model.Token, error) {
+	token, err := datastore.GetTokenByHash(ctx, HashToken(raw))
+	if err == datastore.ErrNotFound {
+		return nil, nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := datastore.GetUser(ctx, token.UserID)
+	if err == datastore.ErrNotFound {
+		return nil, nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, token, nil
+}
+
+type tokenPrivate struct{}
+
+var tokenContextKey tokenPrivate
+
+func NewTokenContext(parent context.Context, token *model.Token) context.Context {
+	return context.WithValue(parent, tokenContextKey, token)
+}
+
+// TokenFromContext returns the API token that the request was authenticated
+// with, or nil if it wasn't authenticated with a token.
+func TokenFromContext(c context.Context) *model.Token {
+	token, _ := c.Value(tokenContextKey).(*model.Token)
+	return token
+}
@@ -1,13 +1,7 @@
 package conf
 
 import (
-	"crypto/rand"
-	"encoding/hex"
-	"encoding/json"
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/comail/colog"
@@ -25,19 +19,90 @@ var (
 
 type Config struct {
 	// Current environment (e.g. 'production', 'debug').  This is set from
-	// an environment variable.
+	// an environment variable or command-line flag.
 	Environment string `json:"-"`
 
 	// Web configuration
-	Host          string `json:"host"`
-	Port          uint16 `json:"port"`
-	SessionSecret string `json:"session_secret"`
+	Host string `json:"host"`
+	Port uint16 `json:"port"`
+
+	// Secret used to sign and encrypt sessions, or a file to read it from.
+	// Each line of the file after the first is a previous secret.
+	SessionSecret     string `json:"session_secret"`
+	SessionSecretFile string `json:"session_secret_file"`
+
+	// Secrets that were previously used, which are still accepted when
+	// verifying existing sessions.  This allows rotating the secret without
+	// invalidating every session at once.
+	PreviousSessionSecrets []string `json:"previous_session_secrets"`
+
+	// Where sessions are stored: "cookie" (in a signed cookie, encrypted if
+	// SessionEncrypt is set) or "database".
+	SessionStore   string `json:"session_store"`
+	SessionEncrypt bool   `json:"session_encrypt"`
+
+	// Graceful shutdown: on SIGINT or SIGTERM, the server reports that it
+	// isn't ready (at /readyz) for ShutdownDrain seconds, so that load
+	// balancers stop sending it requests, and then gives requests in
+	// progress up to ShutdownTimeout seconds to finish.
+	ShutdownDrain   int `json:"shutdown_drain"`
+	ShutdownTimeout int `json:"shutdown_timeout"`
+
+	// Maximum size of a request body, in bytes.
+	MaxBodySize int64 `json:"max_body_size"`
+
+	// Cross-origin (CORS) policy for API routes.  Origins are given as
+	// "scheme://host[:port]", and may use a wildcard for any subdomain (e.g.
+	// "https://*.example.com"), or be "*" to allow every origin.  No
+	// cross-origin requests are allowed if CORSOrigins is empty.  If
+	// CORSMethods is empty, all methods that a route accepts are allowed.
+	CORSOrigins       []string `json:"cors_origins"`
+	CORSMethods       []string `json:"cors_methods"`
+	CORSHeaders       []string `json:"cors_headers"`
+	CORSExposeHeaders []string `json:"cors_expose_headers"`
+	CORSCredentials   bool     `json:"cors_credentials"`
+	CORSMaxAge        int      `json:"cors_max_age"` // in seconds
+
+	// Directory to serve static files from, instead of the files embedded
+	// in the binary.
+	StaticDir string `json:"static_dir"`
+
+	// Single-page app mode.  If SPA is set, GET requests that accept HTML
+	// and don't match any other route are served SPAIndex (a static file),
+	// so that an app that does its own routing can handle them.  If
+	// SPABootstrap is set, data for the app (such as the current user and a
+	// CSRF token) is embedded in the page.
+	SPA          bool   `json:"spa"`
+	SPAIndex     string `json:"spa_index"`
+	SPABootstrap bool   `json:"spa_bootstrap"`
 
 	// DB configuration
 	DbType string `json:"dbtype"`
 	DbConn string `json:"dbconn"`
 }
 
+// Default returns the default configuration.
+func Default() *Config {
+	return &Config{
+		Environment:  "debug",
+		Host:         "localhost",
+		Port:         3001,
+		MaxBodySize:  1 << 20,
+		SessionStore: "cookie",
+
+		ShutdownTimeout: 10,
+
+		CORSHeaders:       []string{"Authorization", "Content-Type", "X-CSRF-Token"},
+		CORSExposeHeaders: []string{"Link", "X-Total-Count", "X-CSRF-Token"},
+		CORSMaxAge:        600,
+
+		SPAIndex: "index.html",
+
+		DbType: "sqlite3",
+		DbConn: ":memory:",
+	}
+}
+
 func (c *Config) HostString() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
@@ -50,71 +115,138 @@ func (c *Config) IsProduction() bool {
 	return c.Environment == "production" || c.Environment == "prod"
 }
 
-var (
-	ConfigPath      = filepath.Join(".", "config.json")
-	configPathGiven bool
+// MinSecretLength is the minimum length of a session secret.
+const MinSecretLength = 32
 
-	C = &Config{}
-)
+// SessionKeys returns the keyring of session secrets: the current secret,
+// which should be used to sign new sessions, followed by the previous
+// secrets, which should only be used to verify existing sessions.
+func (c *Config) SessionKeys() [][]byte {
+	keys := [][]byte{[]byte(c.SessionSecret)}
+	for _, secret := range c.PreviousSessionSecrets {
+		keys = append(keys, []byte(secret))
+	}
+	return keys
+}
+
+// Errors is a list of problems found in a configuration.
+type Errors []string
+
+func (e Errors) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e, "\n  - ")
+}
+
+// Validate checks that the configuration is usable, returning all problems
+// that were found as Errors.
+func (c *Config) Validate() error {
+	var errs Errors
 
-func init() {
-	if ProjectName == "" {
-		panic("no project name set - did you use the Makefile to build?")
+	if c.Environment == "" {
+		errs = append(errs, "environment must not be empty")
+	}
+	if c.Port == 0 {
+		errs = append(errs, "port must not be 0")
+	}
+	if c.MaxBodySize <= 0 {
+		errs = append(errs, "max_body_size must be positive")
+	}
+	if c.ShutdownDrain < 0 {
+		errs = append(errs, "shutdown_drain must not be negative")
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, "shutdown_timeout must be positive")
 	}
 
-	// Set defaults
-	C.Environment = "debug"
-	C.Host = "localhost"
-	C.Port = 3001
-	C.DbType = "sqlite3"
-	C.DbConn = ":memory:"
+	switch {
+	case c.SessionSecret == "" && c.IsProduction():
+		errs = append(errs, "session_secret or session_secret_file must be set in production")
+	case c.SessionSecret != "" && len(c.SessionSecret) < MinSecretLength:
+		errs = append(errs, fmt.Sprintf("session_secret must be at least %d characters long", MinSecretLength))
+	}
+	for i, secret := range c.PreviousSessionSecrets {
+		if len(secret) < MinSecretLength {
+			errs = append(errs, fmt.Sprintf("previous session secret %d must be at least %d characters long", i+1, MinSecretLength))
+		}
+	}
 
-	// Load the environment
-	C.Environment = os.Getenv("ENVIRONMENT")
+	switch c.SessionStore {
+	case "cookie", "database":
+	default:
+		errs = append(errs, fmt.Sprintf("session_store %q is not supported (must be one of: cookie, database)", c.SessionStore))
+	}
 
-	// Register colog to handle the standard logger output
-	colog.Register()
-	colog.SetFlags(0)
-	colog.ParseFields(true)
+	for _, origin := range c.CORSOrigins {
+		switch {
+		case origin == "*" && c.CORSCredentials:
+			errs = append(errs, `cors_origins must not contain "*" when cors_credentials is set`)
+		case origin != "*" && !validOrigin(origin):
+			errs = append(errs, fmt.Sprintf("cors_origins: invalid origin %q (must be scheme://host[:port])", origin))
+		}
+	}
+	if c.CORSMaxAge < 0 {
+		errs = append(errs, "cors_max_age must not be negative")
+	}
 
-	// Set up logger
-	if C.IsDebug() {
-		colog.SetMinLevel(colog.LDebug)
-	} else {
-		colog.SetMinLevel(colog.LInfo)
-		colog.SetFormatter(&colog.JSONFormatter{})
+	if c.SPA && c.SPAIndex == "" {
+		errs = append(errs, "spa_index must not be empty when spa is set")
+	}
+
+	switch c.DbType {
+	case "sqlite3", "postgres", "mysql":
+	default:
+		errs = append(errs, fmt.Sprintf("dbtype %q is not supported (must be one of: sqlite3, postgres, mysql)", c.DbType))
+	}
+	if c.DbConn == "" {
+		errs = append(errs, "dbconn must not be empty")
 	}
 
-	// Generate a random session secret.
-	buf := make([]byte, 20)
-	if _, err := rand.Read(buf); err != nil {
-		log.Printf("error: could not generate random secret: %s\n", err)
-		os.Exit(1)
-		return
+	if len(errs) > 0 {
+		return errs
 	}
-	C.SessionSecret = hex.EncodeToString(buf)
+	return nil
+}
 
-	// Let the user override the config file path.
-	if cpath := os.Getenv(strings.ToUpper(ProjectName) + "_CONFIG_PATH"); cpath != "" {
-		ConfigPath = cpath
-		configPathGiven = true
+// validOrigin returns whether the given string is an origin, optionally with
+// a wildcard subdomain.
+func validOrigin(origin string) bool {
+	i := strings.Index(origin, "://")
+	if i <= 0 || !validScheme(origin[:i]) {
+		return false
 	}
 
-	// Read the configuration file, if present.
-	f, err := os.Open(ConfigPath)
-	if err != nil {
-		// We don't print an error if the user did not give a config path, and
-		// the default config file does not exist.
-		if !configPathGiven && os.IsNotExist(err) {
-			// Do nothing
-		} else {
-			log.Printf("error: could not read configuration file `%s`: %s\n", ConfigPath, err)
+	host := strings.TrimPrefix(origin[i+3:], "*.")
+	return host != "" && !strings.ContainsAny(host, "/*?#\"' ")
+}
+
+// validScheme returns whether the given string is a URL scheme.
+func validScheme(scheme string) bool {
+	for i, r := range scheme {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && ('0' <= r && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
 		}
-		return
 	}
-	defer f.Close()
+	return true
+}
+
+// C is the configuration of the running program.  It holds the default
+// configuration until it is replaced by the result of Load.
+var C = Default()
+
+// ConfigureLogging sets up the standard logger according to the given
+// configuration.
+func ConfigureLogging(c *Config) {
+	// Register colog to handle the standard logger output
+	colog.Register()
+	colog.SetFlags(0)
+	colog.ParseFields(true)
 
-	if err := json.NewDecoder(f).Decode(C); err != nil {
-		log.Printf("error: could not decode configuration file: %s\n", err)
+	if c.IsDebug() {
+		colog.SetMinLevel(colog.LDebug)
+	} else {
+		colog.SetMinLevel(colog.LInfo)
+		colog.SetFormatter(&colog.JSONFormatter{})
 	}
 }
@@ -0,0 +1,285 @@
+package conf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"go.yaml.in/yaml/v3"
+)
+
+// Options controls how a configuration is loaded.
+type Options struct {
+	// Name of the project, used as the prefix for environment variables.
+	// Defaults to ProjectName.
+	ProjectName string
+
+	// Path to the configuration file.  If empty, the path is taken from the
+	// <PROJECT>_CONFIG_PATH environment variable or the -config flag, and
+	// otherwise defaults to "config.json" (which need not exist).
+	ConfigPath string
+
+	// Environment variables, in the form returned by os.Environ.
+	Env []string
+
+	// Command-line arguments, not including the program name.
+	Args []string
+}
+
+// Load builds a configuration from, in increasing order of precedence:
+//
+//     - the defaults (see Default)
+//     - the configuration file (JSON, TOML or YAML, based on its extension)
+//     - environment variables named <PROJECT>_<KEY>, e.g. SKELETON_PORT
+//     - command-line flags, e.g. -port
+//
+// where the keys are the JSON names of the Config fields.  The environment
+// is taken from the ENVIRONMENT variable or the -env flag.
+//
+// Load does not modify any global state; callers should assign the result to
+// C themselves.  The command-line arguments remaining after the flags are
+// returned.
+func Load(opts *Options) (*Config, []string, error) {
+	projectName := opts.ProjectName
+	if projectName == "" {
+		projectName = ProjectName
+	}
+	if projectName == "" {
+		return nil, nil, fmt.Errorf("no project name set - did you use the Makefile to build?")
+	}
+	prefix := strings.ToUpper(projectName) + "_"
+
+	env := make(map[string]string)
+	for _, kv := range opts.Env {
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+
+	// Parse the flags first, since they can give the config file path, but
+	// only apply them once everything else has been loaded.
+	fs := flag.NewFlagSet(projectName, flag.ContinueOnError)
+	var (
+		configPath  = fs.String("config", "", "path to the configuration file")
+		environment = fs.String("env", "", "current environment (e.g. 'debug', 'production')")
+		flagValues  = make(map[string]*string)
+	)
+	for _, key := range configKeys() {
+		flagName := strings.Replace(key, "_", "-", -1)
+		flagValues[key] = fs.String(flagName, "", "sets the '"+key+"' configuration value")
+	}
+	if err := fs.Parse(opts.Args); err != nil {
+		return nil, nil, err
+	}
+
+	c := Default()
+
+	// Configuration file
+	path, pathGiven := opts.ConfigPath, opts.ConfigPath != ""
+	if !pathGiven {
+		path, pathGiven = env[prefix+"CONFIG_PATH"], env[prefix+"CONFIG_PATH"] != ""
+	}
+	if *configPath != "" {
+		path, pathGiven = *configPath, true
+	}
+	if !pathGiven {
+		path = filepath.Join(".", "config.json")
+	}
+	if err := loadFile(c, path); err != nil {
+		// We don't return an error if the user did not give a config
+		// path, and the default config file does not exist.
+		if pathGiven || !os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("could not read configuration file `%s`: %s", path, err)
+		}
+	}
+
+	// Environment variables
+	if v, ok := env["ENVIRONMENT"]; ok && v != "" {
+		c.Environment = v
+	}
+	for _, key := range configKeys() {
+		name := prefix + strings.ToUpper(key)
+		if v, ok := env[name]; ok {
+			if err := setKey(c, key, v); err != nil {
+				return nil, nil, fmt.Errorf("environment variable %s: %s", name, err)
+			}
+		}
+	}
+
+	// Command-line flags
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "env" {
+			c.Environment = *environment
+			return
+		}
+
+		key := strings.Replace(f.Name, "-", "_", -1)
+		if v, ok := flagValues[key]; ok && flagErr == nil {
+			if err := setKey(c, key, *v); err != nil {
+				flagErr = fmt.Errorf("flag -%s: %s", f.Name, err)
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, nil, flagErr
+	}
+
+	// Read the session secrets from a file, if one was given.
+	if c.SessionSecretFile != "" {
+		if c.SessionSecret != "" {
+			return nil, nil, fmt.Errorf("only one of session_secret and session_secret_file may be set")
+		}
+		if err := loadSecretFile(c, c.SessionSecretFile); err != nil {
+			return nil, nil, fmt.Errorf("could not read session secret file `%s`: %s", c.SessionSecretFile, err)
+		}
+	}
+
+	// Generate a random session secret if none was given.  Sessions won't
+	// survive a restart, so this isn't allowed in production (see
+	// Validate).
+	if c.SessionSecret == "" && !c.IsProduction() {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("could not generate random secret: %s", err)
+		}
+		c.SessionSecret = hex.EncodeToString(buf)
+		log.Printf("warning: no session secret configured, using a random one")
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return c, fs.Args(), nil
+}
+
+// loadFile reads the configuration file at the given path into c.  TOML and
+// YAML files are converted to JSON first, so that every format has the same
+// keys (the JSON names of the Config fields) and is checked the same way.
+func loadFile(c *Config, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml", ".yaml", ".yml":
+		values := make(map[string]interface{})
+		if ext == ".toml" {
+			err = toml.Unmarshal(data, &values)
+		} else {
+			err = yaml.Unmarshal(data, &values)
+		}
+		if err == nil {
+			data, err = json.Marshal(values)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(c)
+}
+
+// loadSecretFile reads the session secrets from the file at the given path.
+// The first non-empty line is the current secret, and any further lines are
+// previous secrets.
+func loadSecretFile(c *Config, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var secrets []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			secrets = append(secrets, line)
+		}
+	}
+	if len(secrets) == 0 {
+		return fmt.Errorf("file is empty")
+	}
+
+	c.SessionSecret = secrets[0]
+	c.PreviousSessionSecrets = append(c.PreviousSessionSecrets, secrets[1:]...)
+	return nil
+}
+
+// configKeys returns the keys of all configurable fields, which are the
+// names from their JSON tags.
+func configKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("json"); key != "" && key != "-" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// setKey parses the given string and sets the field with the given key.
+func setKey(c *Config, key, value string) error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("json") != key {
+			continue
+		}
+
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(value)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(value, 10, f.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("invalid number %q", value)
+			}
+			f.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(value, 10, f.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("invalid number %q", value)
+			}
+			f.SetUint(n)
+		case reflect.Slice:
+			if f.Type().Elem().Kind() != reflect.String {
+				return fmt.Errorf("unsupported type %s", f.Type())
+			}
+
+			// Lists are given as comma-separated values.
+			var items []string
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			f.Set(reflect.ValueOf(items))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", value)
+			}
+			f.SetBool(b)
+		default:
+			return fmt.Errorf("unsupported type %s", f.Type())
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown configuration key")
+}
@@ -0,0 +1,271 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// load calls Load with the given environment and arguments, and a
+// configuration file with the given name and contents (if name isn't empty).
+func load(t *testing.T, name, contents string, env []string, args ...string) (*Config, error) {
+	t.Helper()
+
+	opts := &Options{ProjectName: "skeleton", Env: env, Args: args}
+	if name != "" {
+		opts.ConfigPath = filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(opts.ConfigPath, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, _, err := Load(opts)
+	return c, err
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	const file = `{"port": 4000, "host": "file.example.com"}`
+
+	tests := []struct {
+		name     string
+		file     string
+		env      []string
+		args     []string
+		wantPort uint16
+		wantHost string
+	}{
+		{
+			name:     "defaults",
+			wantPort: 3001,
+			wantHost: "localhost",
+		},
+		{
+			name:     "file over defaults",
+			file:     file,
+			wantPort: 4000,
+			wantHost: "file.example.com",
+		},
+		{
+			name:     "env over file",
+			file:     file,
+			env:      []string{"SKELETON_PORT=5000"},
+			wantPort: 5000,
+			wantHost: "file.example.com",
+		},
+		{
+			name:     "flags over env",
+			file:     file,
+			env:      []string{"SKELETON_PORT=5000", "SKELETON_HOST=env.example.com"},
+			args:     []string{"-port", "6000"},
+			wantPort: 6000,
+			wantHost: "env.example.com",
+		},
+		{
+			name:     "flags over file",
+			file:     file,
+			args:     []string{"-host", "flag.example.com"},
+			wantPort: 4000,
+			wantHost: "flag.example.com",
+		},
+		{
+			name:     "other project's env ignored",
+			env:      []string{"OTHER_PORT=5000"},
+			wantPort: 3001,
+			wantHost: "localhost",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := ""
+			if tt.file != "" {
+				name = "config.json"
+			}
+			c, err := load(t, name, tt.file, tt.env, tt.args...)
+			if err != nil {
+				t.Fatalf("Load: %s", err)
+			}
+			if c.Port != tt.wantPort || c.Host != tt.wantHost {
+				t.Errorf("got %s:%d, want %s:%d", c.Host, c.Port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestLoadEnvironment(t *testing.T) {
+	const secret = "-session-secret=0123456789abcdef0123456789abcdef"
+
+	c, err := load(t, "", "", []string{"ENVIRONMENT=production"}, secret)
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if !c.IsProduction() {
+		t.Errorf("Environment = %q, want production", c.Environment)
+	}
+
+	c, err = load(t, "", "", []string{"ENVIRONMENT=production"}, secret, "-env", "staging")
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if c.Environment != "staging" {
+		t.Errorf("Environment = %q, want the -env flag to override ENVIRONMENT", c.Environment)
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	want := []string{"https://a.example.com", "https://b.example.com"}
+
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"config.json", `{"port": 4000, "session_encrypt": true, "cors_origins": ["https://a.example.com", "https://b.example.com"]}`},
+		{"config.toml", `
+# Comment
+port = 4000
+session_encrypt = true
+cors_origins = ["https://a.example.com", "https://b.example.com"]
+`},
+		{"config.yaml", `
+# Comment
+port: 4000
+session_encrypt: true
+cors_origins:
+  - https://a.example.com
+  - https://b.example.com
+`},
+		{"config.yml", `{port: 4000, session_encrypt: true, cors_origins: [https://a.example.com, https://b.example.com]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := load(t, tt.name, tt.contents, nil)
+			if err != nil {
+				t.Fatalf("Load: %s", err)
+			}
+			if c.Port != 4000 || !c.SessionEncrypt || !reflect.DeepEqual(c.CORSOrigins, want) {
+				t.Errorf("got port=%d session_encrypt=%t cors_origins=%q", c.Port, c.SessionEncrypt, c.CORSOrigins)
+			}
+		})
+	}
+}
+
+func TestLoadLists(t *testing.T) {
+	want := []string{"https://a.example.com", "https://b.example.com"}
+
+	c, err := load(t, "", "", []string{"SKELETON_CORS_ORIGINS=https://a.example.com, https://b.example.com"})
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if !reflect.DeepEqual(c.CORSOrigins, want) {
+		t.Errorf("from env: cors_origins = %q, want %q", c.CORSOrigins, want)
+	}
+
+	c, err = load(t, "", "", nil, "-cors-origins", "https://a.example.com,https://b.example.com")
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if !reflect.DeepEqual(c.CORSOrigins, want) {
+		t.Errorf("from flag: cors_origins = %q, want %q", c.CORSOrigins, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string // config.json, unless it starts with a file name
+		env   []string
+		args  []string
+		wants []string
+	}{
+		{
+			name:  "port zero",
+			args:  []string{"-port", "0"},
+			wants: []string{"port must not be 0"},
+		},
+		{
+			name:  "invalid number",
+			env:   []string{"SKELETON_PORT=http"},
+			wants: []string{"SKELETON_PORT", `invalid number "http"`},
+		},
+		{
+			name:  "unknown flag",
+			args:  []string{"-no-such-flag"},
+			wants: []string{"no-such-flag"},
+		},
+		{
+			name:  "unknown key in file",
+			file:  `{"no_such_key": 1}`,
+			wants: []string{"no_such_key"},
+		},
+		{
+			name:  "wrong type in file",
+			file:  "config.toml\nport = \"http\"",
+			wants: []string{"port"},
+		},
+		{
+			name:  "malformed yaml",
+			file:  "config.yaml\nport: [",
+			wants: []string{"config.yaml"},
+		},
+		{
+			name:  "missing file",
+			args:  []string{"-config", "/nonexistent/config.json"},
+			wants: []string{"could not read configuration file", "/nonexistent/config.json"},
+		},
+		{
+			name:  "no secret in production",
+			args:  []string{"-env", "production"},
+			wants: []string{"session_secret or session_secret_file must be set in production"},
+		},
+		{
+			name:  "short secret",
+			args:  []string{"-session-secret", "short"},
+			wants: []string{"session_secret must be at least 32 characters long"},
+		},
+		{
+			name:  "invalid origin",
+			args:  []string{"-cors-origins", `["https://a.example.com",example.com`},
+			wants: []string{`invalid origin "[\"https://a.example.com\""`, `invalid origin "example.com"`},
+		},
+		{
+			name:  "every problem reported",
+			args:  []string{"-dbtype", "oracle", "-dbconn", "", "-session-store", "memcached", "-max-body-size", "0"},
+			wants: []string{`dbtype "oracle" is not supported`, "dbconn must not be empty", `session_store "memcached" is not supported`, "max_body_size must be positive"},
+		},
+		{
+			name:  "no shutdown timeout",
+			args:  []string{"-shutdown-timeout", "0"},
+			wants: []string{"shutdown_timeout must be positive"},
+		},
+		{
+			name:  "negative durations",
+			args:  []string{"-shutdown-drain", "-1", "-cors-max-age", "-1"},
+			wants: []string{"shutdown_drain must not be negative", "cors_max_age must not be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, contents := "", tt.file
+			if contents != "" {
+				name = "config.json"
+				if i := strings.IndexByte(contents, '\n'); i >= 0 {
+					name, contents = contents[:i], contents[i+1:]
+				}
+			}
+
+			_, err := load(t, name, contents, tt.env, tt.args...)
+			if err == nil {
+				t.Fatalf("Load succeeded, want an error")
+			}
+			for _, want := range tt.wants {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -1,7 +1,8 @@
 package database
 
 import (
-	"github.com/BurntSushi/migration"
+	"strings"
+
 	"github.com/jmoiron/sqlx"
 
 	"github.com/andrew-d/go-webapp-skeleton/datastore"
@@ -12,27 +13,75 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func Connect(driver, conn string) (*sqlx.DB, error) {
-	migrator := migrate.Migrator{driver}
-
-	migration.DefaultGetVersion = migrator.GetVersion
-	migration.DefaultSetVersion = migrator.SetVersion
+// Open connects to the database, without applying any migrations.
+func Open(driver, conn string) (*sqlx.DB, error) {
+	// SQLite only enforces foreign keys (and so ON DELETE CASCADE) on
+	// connections that turn them on, which the driver does for every
+	// connection in the pool when asked to in the connection string.
+	if driver == "sqlite3" {
+		conn = addParam(conn, "_foreign_keys", "1", "_fk")
+	}
 
-	migrations := []migration.Migrator{
-		migrator.Setup,
-		migrator.CreateDefaultPerson,
+	// MySQL counts the rows that an UPDATE changed, rather than those that
+	// it matched, unless asked not to.  We need the latter to tell whether
+	// the row being updated exists.
+	if driver == "mysql" {
+		conn = addParam(conn, "clientFoundRows", "true")
 	}
 
-	db, err := migration.Open(driver, conn, migrations)
+	db, err := sqlx.Open(driver, conn)
 	if err != nil {
 		return nil, err
 	}
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	return sqlx.NewDb(db, driver), nil
+	return db, nil
+}
+
+// addParam adds the query parameter key=value to the connection string conn,
+// unless it (or one of its aliases) is already set.
+func addParam(conn, key, value string, aliases ...string) string {
+	i := strings.IndexByte(conn, '?')
+	if i >= 0 {
+		for _, param := range strings.Split(conn[i+1:], "&") {
+			name := strings.SplitN(param, "=", 2)[0]
+			if name == key {
+				return conn
+			}
+			for _, alias := range aliases {
+				if name == alias {
+					return conn
+				}
+			}
+		}
+		return conn + "&" + key + "=" + value
+	}
+	return conn + "?" + key + "=" + value
+}
+
+// Connect connects to the database and applies any pending migrations.  It
+// fails if any migration that was already applied has since been changed.
+func Connect(driver, conn string) (*sqlx.DB, error) {
+	db, err := Open(driver, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := NewMigrator(db).Up(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// NewMigrator returns the migrator for the given database.
+func NewMigrator(db *sqlx.DB) migrate.Migrator {
+	return migrate.Migrator{DbType: db.DriverName()}
 }
 
 func MustConnect(driver, conn string) *sqlx.DB {
@@ -46,7 +95,13 @@ func MustConnect(driver, conn string) *sqlx.DB {
 func NewDatastore(db *sqlx.DB) datastore.Datastore {
 	return struct {
 		*PeopleStore
+		*UserStore
+		*TokenStore
+		*RoleStore
 	}{
 		NewPeopleStore(db),
+		NewUserStore(db),
+		NewTokenStore(db),
+		NewRoleStore(db),
 	}
 }
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+)
+
+// translateError converts an error returned by the database driver into one
+// of the sentinel errors from the datastore package, where possible.  Errors
+// that don't have a datastore equivalent are returned unchanged.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == sql.ErrNoRows {
+		return datastore.ErrNotFound
+	}
+
+	switch e := err.(type) {
+	case *pq.Error:
+		switch e.Code.Class() {
+		case "23": // integrity_constraint_violation
+			switch e.Code.Name() {
+			case "unique_violation", "foreign_key_violation", "exclusion_violation":
+				return datastore.ErrConflict
+			default:
+				return datastore.ErrValidation
+			}
+		case "22": // data_exception
+			return datastore.ErrValidation
+		}
+
+	case *mysql.MySQLError:
+		switch e.Number {
+		case 1062, // ER_DUP_ENTRY
+			1451, // ER_ROW_IS_REFERENCED_2
+			1452: // ER_NO_REFERENCED_ROW_2
+			return datastore.ErrConflict
+		case 1048, // ER_BAD_NULL_ERROR
+			1264, // ER_WARN_DATA_OUT_OF_RANGE
+			1366, // ER_TRUNCATED_WRONG_VALUE_FOR_FIELD
+			1406: // ER_DATA_TOO_LONG
+			return datastore.ErrValidation
+		}
+
+	default:
+		if e := translateSQLiteError(err); e != nil {
+			return e
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,10 @@
+//go:build !cgo
+// +build !cgo
+
+package database
+
+// translateSQLiteError is a no-op without cgo, since the sqlite3 driver can't
+// be built then, and so never returns an error.
+func translateSQLiteError(err error) error {
+	return nil
+}
@@ -0,0 +1,31 @@
+//go:build cgo
+// +build cgo
+
+package database
+
+import (
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+)
+
+// translateSQLiteError converts an error from the sqlite3 driver into one of
+// the sentinel errors from the datastore package.  It returns nil for any
+// other error.
+func translateSQLiteError(err error) error {
+	e, ok := err.(sqlite3.Error)
+	if !ok {
+		return nil
+	}
+
+	switch e.ExtendedCode {
+	case sqlite3.ErrConstraintUnique,
+		sqlite3.ErrConstraintPrimaryKey,
+		sqlite3.ErrConstraintForeignKey:
+		return datastore.ErrConflict
+	case sqlite3.ErrConstraintNotNull,
+		sqlite3.ErrConstraintCheck:
+		return datastore.ErrValidation
+	}
+	return nil
+}
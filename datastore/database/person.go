@@ -3,6 +3,7 @@ package database
 import (
 	"github.com/jmoiron/sqlx"
 
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
 	"github.com/andrew-d/go-webapp-skeleton/model"
 )
 
@@ -14,25 +15,144 @@ func NewPeopleStore(db *sqlx.DB) *PeopleStore {
 	return &PeopleStore{db}
 }
 
-func (s *PeopleStore) ListPeople(limit, offset int) ([]*model.Person, error) {
+// personSortColumns maps the fields that people can be sorted by to columns.
+var personSortColumns = map[string]string{
+	"id":   "id",
+	"name": "name",
+}
+
+// People are listed newest-first by default.
+var personDefaultSort = []sortColumn{{"id", true}}
+
+func (s *PeopleStore) ListPeople(filter *datastore.PeopleFilter, opts *datastore.ListOptions) ([]*model.Person, *datastore.Page, error) {
+	var (
+		conds     []string
+		args      []interface{}
+		backwards = opts.Cursor != nil && opts.Cursor.Before
+	)
+
+	cols, err := resolveSort(opts.Sort, personSortColumns, personDefaultSort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Build the filter conditions.
+	if filter != nil && filter.Name != "" {
+		cond, arg := likeCondition("name", filter.Name, filter.NamePrefix)
+		conds = append(conds, cond)
+		args = append(args, arg)
+	}
+	if filter != nil && len(filter.IDs) > 0 {
+		conds = append(conds, "id IN (?)")
+		args = append(args, filter.IDs)
+	}
+	countConds, countArgs := conds, args
+
+	// Only fetch people past the cursor.
+	if opts.Cursor != nil {
+		cond, cargs, err := keysetCondition(cols, opts.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		conds = append(conds, cond)
+		args = append(args, cargs...)
+	}
+
+	// We fetch one extra person to find out if there are more people past
+	// the end of this page.  When paging backwards, the sort order is
+	// reversed so the people closest to the cursor come first.
+	query := personListQuery + where(conds) + "\n" + orderBy(cols, backwards) + "\nLIMIT ?"
+	args = append(args, opts.Limit+1)
+
+	query, args, err = sqlx.In(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	people := []*model.Person{}
-	err := s.db.Select(&people, s.db.Rebind(personListQuery), limit, offset)
-	return people, err
+	if err := s.db.Select(&people, s.db.Rebind(query), args...); err != nil {
+		return nil, nil, translateError(err)
+	}
+
+	hasMore := len(people) > opts.Limit
+	if hasMore {
+		people = people[:opts.Limit]
+	}
+
+	if backwards {
+		for i, j := 0, len(people)-1; i < j; i, j = i+1, j-1 {
+			people[i], people[j] = people[j], people[i]
+		}
+	}
+
+	page := &datastore.Page{Total: -1}
+	if len(people) > 0 {
+		first, last := people[0], people[len(people)-1]
+		if hasMore || backwards {
+			page.Next = personCursor(last, cols)
+		}
+		if (hasMore && backwards) || (opts.Cursor != nil && !backwards) {
+			page.Prev = personCursor(first, cols)
+			page.Prev.Before = true
+		}
+	}
+
+	if opts.IncludeTotal {
+		query, args, err := sqlx.In(personCountQuery+where(countConds), countArgs...)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.db.Get(&page.Total, s.db.Rebind(query), args...); err != nil {
+			return nil, nil, translateError(err)
+		}
+	}
+
+	return people, page, nil
+}
+
+// personCursor returns a cursor positioned at the given person, for a list
+// sorted by the given columns.
+func personCursor(person *model.Person, cols []sortColumn) *datastore.Cursor {
+	c := &datastore.Cursor{ID: person.ID}
+	for _, col := range cols[:len(cols)-1] {
+		switch col.name {
+		case "name":
+			c.Values = append(c.Values, person.Name)
+		}
+	}
+	return c
 }
 
 func (s *PeopleStore) GetPerson(id int64) (*model.Person, error) {
 	person := &model.Person{}
 	err := s.db.Get(person, s.db.Rebind(personGetQuery), id)
-	return person, err
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return person, nil
 }
 
 func (s *PeopleStore) CreatePerson(person *model.Person) error {
-	ret, err := s.db.Exec(RebindInsert(s.db, personInsertQuery), person.Name)
+	id, err := insert(s.db, RebindInsert(s.db, personInsertQuery), person.Name)
+	if err != nil {
+		return translateError(err)
+	}
+
+	person.ID = id
+	return nil
+}
+
+func (s *PeopleStore) UpdatePerson(person *model.Person) error {
+	ret, err := s.db.Exec(s.db.Rebind(personUpdateQuery), person.Name, person.ID)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 
-	person.ID, _ = ret.LastInsertId()
+	// This counts the rows that matched, rather than those that changed,
+	// even on MySQL (see Open).
+	if n, _ := ret.RowsAffected(); n == 0 {
+		return datastore.ErrNotFound
+	}
 	return nil
 }
 
@@ -41,7 +161,7 @@ func (s *PeopleStore) DeletePerson(id int64) (err error) {
 
 	tx, err = s.db.Beginx()
 	if err != nil {
-		return
+		return translateError(err)
 	}
 
 	// Automatically rollback/commit if there's an error.
@@ -54,8 +174,14 @@ func (s *PeopleStore) DeletePerson(id int64) (err error) {
 	}()
 
 	// Remove the given Person
-	if _, err = tx.Exec(s.db.Rebind(personDeleteQuery), id); err != nil {
-		return
+	ret, err := tx.Exec(s.db.Rebind(personDeleteQuery), id)
+	if err != nil {
+		return translateError(err)
+	}
+
+	// Nothing was deleted if the person didn't exist.
+	if n, _ := ret.RowsAffected(); n == 0 {
+		return datastore.ErrNotFound
 	}
 
 	// Done!
@@ -65,8 +191,11 @@ func (s *PeopleStore) DeletePerson(id int64) (err error) {
 const personListQuery = `
 SELECT *
 FROM people
-ORDER BY id DESC
-LIMIT ? OFFSET ?
+`
+
+const personCountQuery = `
+SELECT COUNT(*)
+FROM people
 `
 
 const personGetQuery = `
@@ -83,6 +212,12 @@ INTO people (
 VALUES (?)
 `
 
+const personUpdateQuery = `
+UPDATE people
+SET name = ?
+WHERE id = ?
+`
+
 const personDeleteQuery = `
 DELETE
 FROM people
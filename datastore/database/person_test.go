@@ -0,0 +1,46 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+	"github.com/andrew-d/go-webapp-skeleton/datastore/database"
+	"github.com/andrew-d/go-webapp-skeleton/datastore/migrate"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+func TestUpdatePerson(t *testing.T) {
+	for _, dbType := range migrate.DbTypes {
+		t.Run(dbType, func(t *testing.T) {
+			s := database.NewPeopleStore(open(t, dbType))
+
+			person := &model.Person{Name: "Alice"}
+			if err := s.CreatePerson(person); err != nil {
+				t.Fatalf("CreatePerson: %s", err)
+			}
+
+			tests := []struct {
+				name    string
+				person  model.Person
+				wantErr error
+			}{
+				{"changed", model.Person{ID: person.ID, Name: "Bob"}, nil},
+				{"unchanged", model.Person{ID: person.ID, Name: "Bob"}, nil},
+				{"not found", model.Person{ID: person.ID + 1, Name: "Carol"}, datastore.ErrNotFound},
+			}
+			for _, tt := range tests {
+				if err := s.UpdatePerson(&tt.person); err != tt.wantErr {
+					t.Errorf("%s: UpdatePerson returned %v, want %v", tt.name, err, tt.wantErr)
+				}
+			}
+
+			got, err := s.GetPerson(person.ID)
+			if err != nil {
+				t.Fatalf("GetPerson: %s", err)
+			}
+			if got.Name != "Bob" {
+				t.Errorf("person has name %q, want %q", got.Name, "Bob")
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+)
+
+// sortColumn is a single column in an ORDER BY clause.
+type sortColumn struct {
+	name string
+	desc bool
+}
+
+// resolveSort maps the requested sort fields to columns, using the given map
+// of allowed field names to column names.  The "id" column is always added as
+// the final column (if it isn't already present) so that the ordering is
+// total, which is required for keyset pagination.
+func resolveSort(fields []datastore.SortField, allowed map[string]string, defaults []sortColumn) ([]sortColumn, error) {
+	if len(fields) == 0 {
+		return defaults, nil
+	}
+
+	var cols []sortColumn
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		name, ok := allowed[f.Name]
+		if !ok {
+			return nil, datastore.ErrInvalidSort
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		cols = append(cols, sortColumn{name, f.Desc})
+
+		// Since IDs are unique, anything after them doesn't matter.
+		if name == "id" {
+			return cols, nil
+		}
+	}
+
+	return append(cols, sortColumn{"id", cols[len(cols)-1].desc}), nil
+}
+
+// orderBy returns an ORDER BY clause for the given columns.  If reverse is
+// set, the direction of every column is flipped.
+func orderBy(cols []sortColumn, reverse bool) string {
+	terms := make([]string, len(cols))
+	for i, col := range cols {
+		if col.desc != reverse {
+			terms[i] = col.name + " DESC"
+		} else {
+			terms[i] = col.name + " ASC"
+		}
+	}
+	return "ORDER BY " + strings.Join(terms, ", ")
+}
+
+// keysetCondition returns a condition that matches all records that come
+// after the given cursor, when sorted by the given columns.  If the cursor is
+// a 'before' cursor, it instead matches the records that come before it.
+//
+// For columns (a, b, id), this generates:
+//
+//     (a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?)
+//
+// with the comparisons flipped as appropriate for descending columns.
+func keysetCondition(cols []sortColumn, c *datastore.Cursor) (string, []interface{}, error) {
+	if len(c.Values) != len(cols)-1 {
+		return "", nil, datastore.ErrInvalidCursor
+	}
+
+	// Cursors come from the client, so only allow scalar values.
+	for _, v := range c.Values {
+		switch v.(type) {
+		case string, float64, bool:
+		default:
+			return "", nil, datastore.ErrInvalidCursor
+		}
+	}
+	values := append(append([]interface{}{}, c.Values...), c.ID)
+
+	var (
+		terms []string
+		args  []interface{}
+	)
+	for i, col := range cols {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, cols[j].name+" = ?")
+			args = append(args, values[j])
+		}
+
+		op := " > ?"
+		if col.desc != c.Before {
+			op = " < ?"
+		}
+		parts = append(parts, col.name+op)
+		args = append(args, values[i])
+
+		terms = append(terms, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(terms, " OR ") + ")", args, nil
+}
+
+// where returns a WHERE clause joining the given conditions, or the empty
+// string if there are none.
+func where(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conds, " AND ")
+}
+
+// likeEscaper escapes the wildcard characters in a LIKE pattern.  We use '!'
+// as the escape character, since backslashes are treated differently by
+// MySQL.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// likeCondition returns a case-insensitive LIKE condition (and its argument)
+// that matches the column against the given string, either as a substring or
+// as a prefix.
+func likeCondition(column, s string, prefix bool) (string, interface{}) {
+	pattern := likeEscaper.Replace(strings.ToLower(s)) + "%"
+	if !prefix {
+		pattern = "%" + pattern
+	}
+	return "LOWER(" + column + ") LIKE ? ESCAPE '!'", pattern
+}
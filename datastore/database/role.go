@@ -0,0 +1,64 @@
+package database
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+)
+
+type RoleStore struct {
+	db *sqlx.DB
+}
+
+func NewRoleStore(db *sqlx.DB) *RoleStore {
+	return &RoleStore{db}
+}
+
+func (s *RoleStore) ListUserRoles(userID int64) ([]string, error) {
+	roles := []string{}
+	err := s.db.Select(&roles, s.db.Rebind(userRoleListQuery), userID)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return roles, nil
+}
+
+func (s *RoleStore) AddUserRole(userID int64, role string) error {
+	_, err := s.db.Exec(s.db.Rebind(userRoleInsertQuery), userID, role)
+	return translateError(err)
+}
+
+func (s *RoleStore) RemoveUserRole(userID int64, role string) error {
+	ret, err := s.db.Exec(s.db.Rebind(userRoleDeleteQuery), userID, role)
+	if err != nil {
+		return translateError(err)
+	}
+
+	// Nothing was deleted if the user didn't have the role.
+	if n, _ := ret.RowsAffected(); n == 0 {
+		return datastore.ErrNotFound
+	}
+	return nil
+}
+
+const userRoleListQuery = `
+SELECT role
+FROM user_roles
+WHERE user_id = ?
+ORDER BY role
+`
+
+const userRoleInsertQuery = `
+INSERT
+INTO user_roles (
+     user_id
+    ,role
+)
+VALUES (?, ?)
+`
+
+const userRoleDeleteQuery = `
+DELETE
+FROM user_roles
+WHERE user_id = ? AND role = ?
+`
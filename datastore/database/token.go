@@ -0,0 +1,89 @@
+package database
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+type TokenStore struct {
+	db *sqlx.DB
+}
+
+func NewTokenStore(db *sqlx.DB) *TokenStore {
+	return &TokenStore{db}
+}
+
+func (s *TokenStore) ListTokens(userID int64) ([]*model.Token, error) {
+	tokens := []*model.Token{}
+	err := s.db.Select(&tokens, s.db.Rebind(tokenListQuery), userID)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return tokens, nil
+}
+
+func (s *TokenStore) GetTokenByHash(hash string) (*model.Token, error) {
+	token := &model.Token{}
+	err := s.db.Get(token, s.db.Rebind(tokenGetByHashQuery), hash)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return token, nil
+}
+
+func (s *TokenStore) CreateToken(token *model.Token) error {
+	id, err := insert(s.db, RebindInsert(s.db, tokenInsertQuery),
+		token.UserID, token.Name, token.Hash, token.CreatedAt)
+	if err != nil {
+		return translateError(err)
+	}
+
+	token.ID = id
+	return nil
+}
+
+func (s *TokenStore) DeleteToken(userID, id int64) error {
+	ret, err := s.db.Exec(s.db.Rebind(tokenDeleteQuery), id, userID)
+	if err != nil {
+		return translateError(err)
+	}
+
+	// Nothing was deleted if the token didn't exist, or belongs to someone
+	// else.
+	if n, _ := ret.RowsAffected(); n == 0 {
+		return datastore.ErrNotFound
+	}
+	return nil
+}
+
+const tokenListQuery = `
+SELECT *
+FROM api_tokens
+WHERE user_id = ?
+ORDER BY id
+`
+
+const tokenGetByHashQuery = `
+SELECT *
+FROM api_tokens
+WHERE token_hash = ?
+`
+
+const tokenInsertQuery = `
+INSERT
+INTO api_tokens (
+     user_id
+    ,name
+    ,token_hash
+    ,created_at
+)
+VALUES (?, ?, ?, ?)
+`
+
+const tokenDeleteQuery = `
+DELETE
+FROM api_tokens
+WHERE id = ? AND user_id = ?
+`
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+type UserStore struct {
+	db *sqlx.DB
+}
+
+func NewUserStore(db *sqlx.DB) *UserStore {
+	return &UserStore{db}
+}
+
+func (s *UserStore) GetUser(id int64) (*model.User, error) {
+	user := &model.User{}
+	err := s.db.Get(user, s.db.Rebind(userGetQuery), id)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return user, nil
+}
+
+func (s *UserStore) GetUserByEmail(email string) (*model.User, error) {
+	user := &model.User{}
+	err := s.db.Get(user, s.db.Rebind(userGetByEmailQuery), email)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return user, nil
+}
+
+func (s *UserStore) CreateUser(user *model.User) error {
+	id, err := insert(s.db, RebindInsert(s.db, userInsertQuery), user.Email, user.PasswordHash)
+	if err != nil {
+		return translateError(err)
+	}
+
+	user.ID = id
+	return nil
+}
+
+const userGetQuery = `
+SELECT *
+FROM users
+WHERE id = ?
+`
+
+const userGetByEmailQuery = `
+SELECT *
+FROM users
+WHERE email = ?
+`
+
+const userInsertQuery = `
+INSERT
+INTO users (
+     email
+    ,password_hash
+)
+VALUES (?, ?)
+`
@@ -0,0 +1,56 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore/database"
+	"github.com/andrew-d/go-webapp-skeleton/datastore/migrate"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+func TestDeleteUserCascades(t *testing.T) {
+	for _, dbType := range migrate.DbTypes {
+		t.Run(dbType, func(t *testing.T) {
+			db := open(t, dbType)
+			users := database.NewUserStore(db)
+			tokens := database.NewTokenStore(db)
+			roles := database.NewRoleStore(db)
+
+			// Two users, so we can check that only the deleted user's
+			// tokens and roles go with them.
+			var ids []int64
+			for _, email := range []string{"alice@example.com", "bob@example.com"} {
+				user := &model.User{Email: email}
+				if err := users.CreateUser(user); err != nil {
+					t.Fatalf("CreateUser(%q): %s", email, err)
+				}
+				token := &model.Token{UserID: user.ID, Name: "test", Hash: email}
+				if err := tokens.CreateToken(token); err != nil {
+					t.Fatalf("CreateToken for %q: %s", email, err)
+				}
+				if err := roles.AddUserRole(user.ID, "admin"); err != nil {
+					t.Fatalf("AddUserRole for %q: %s", email, err)
+				}
+				ids = append(ids, user.ID)
+			}
+
+			if _, err := db.Exec(db.Rebind("DELETE FROM users WHERE id = ?"), ids[0]); err != nil {
+				t.Fatalf("could not delete user: %s", err)
+			}
+
+			for i, id := range ids {
+				want := i
+				if got, err := tokens.ListTokens(id); err != nil {
+					t.Fatalf("ListTokens(%d): %s", id, err)
+				} else if len(got) != want {
+					t.Errorf("user %d has %d tokens, want %d", id, len(got), want)
+				}
+				if got, err := roles.ListUserRoles(id); err != nil {
+					t.Fatalf("ListUserRoles(%d): %s", id, err)
+				} else if len(got) != want {
+					t.Errorf("user %d has roles %q, want %d roles", id, got, want)
+				}
+			}
+		})
+	}
+}
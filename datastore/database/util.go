@@ -15,3 +15,20 @@ func RebindInsert(db *sqlx.DB, q string) string {
 
 	return q
 }
+
+// insert runs an INSERT query (which should be passed through RebindInsert)
+// and returns the ID of the new row.  PostgreSQL doesn't support
+// LastInsertId, so the ID is read from the RETURNING clause instead.
+func insert(db *sqlx.DB, q string, args ...interface{}) (int64, error) {
+	if db.DriverName() == "postgres" {
+		var id int64
+		err := db.QueryRow(q, args...).Scan(&id)
+		return id, err
+	}
+
+	ret, err := db.Exec(q, args...)
+	if err != nil {
+		return 0, err
+	}
+	return ret.LastInsertId()
+}
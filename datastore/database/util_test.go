@@ -0,0 +1,52 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore/database"
+	"github.com/andrew-d/go-webapp-skeleton/datastore/dbtest"
+	"github.com/andrew-d/go-webapp-skeleton/datastore/migrate"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+// open returns a new database of the given type, with every migration
+// applied.
+func open(t *testing.T, dbType string) *sqlx.DB {
+	t.Helper()
+
+	db := dbtest.Open(t, dbType)
+	if err := database.NewMigrator(db).Up(db); err != nil {
+		t.Fatalf("could not apply migrations: %s", err)
+	}
+	return db
+}
+
+func TestInsert(t *testing.T) {
+	for _, dbType := range migrate.DbTypes {
+		t.Run(dbType, func(t *testing.T) {
+			s := database.NewPeopleStore(open(t, dbType))
+
+			var prev int64
+			for _, name := range []string{"Alice", "Bob"} {
+				person := &model.Person{Name: name}
+				if err := s.CreatePerson(person); err != nil {
+					t.Fatalf("CreatePerson(%q): %s", name, err)
+				}
+				if person.ID <= prev {
+					t.Errorf("CreatePerson(%q) set ID %d, want an ID greater than %d", name, person.ID, prev)
+				}
+				prev = person.ID
+
+				got, err := s.GetPerson(person.ID)
+				if err != nil {
+					t.Fatalf("could not get person %d: %s", person.ID, err)
+				}
+				if got.Name != name {
+					t.Errorf("person %d has name %q, want %q", person.ID, got.Name, name)
+				}
+			}
+		})
+	}
+}
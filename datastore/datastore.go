@@ -2,4 +2,7 @@ package datastore
 
 type Datastore interface {
 	PeopleStore
+	UserStore
+	TokenStore
+	RoleStore
 }
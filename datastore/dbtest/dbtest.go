@@ -0,0 +1,138 @@
+// Package dbtest provides databases for tests that need to run against every
+// supported type of database (see migrate.DbTypes).
+//
+// SQLite is available whenever the tests are built with cgo.  PostgreSQL and
+// MySQL need a server, given by a connection string in an environment
+// variable.  Tests for a type of database that isn't available are skipped,
+// unless the CI environment variable is set, in which case they fail.  For
+// example, with Docker:
+//
+//     docker run -d -p 5432:5432 -e POSTGRES_HOST_AUTH_METHOD=trust postgres
+//     docker run -d -p 3306:3306 -e MYSQL_ROOT_PASSWORD=test mysql:5.7
+//     export TEST_POSTGRES_CONN='postgres://postgres@localhost/postgres?sslmode=disable'
+//     export TEST_MYSQL_CONN='root:test@tcp(localhost:3306)/'
+//     go test ./datastore/...
+//
+// Every test gets a new database, which is created on that server and
+// dropped when the test finishes, so the user must be allowed to create
+// databases.  The CI workflow (.github/workflows/test.yml) runs the tests
+// against PostgreSQL and MySQL service containers in the same way.
+package dbtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore/database"
+)
+
+// Env maps a database type to the environment variable holding the
+// connection string of a server to test against.
+var Env = map[string]string{
+	"postgres": "TEST_POSTGRES_CONN",
+	"mysql":    "TEST_MYSQL_CONN",
+}
+
+// Open returns a connection to a new, empty database of the given type,
+// opened with database.Open, which is closed (and dropped) when the test
+// finishes.  It skips the test if that type of database isn't available,
+// unless running in CI.
+func Open(t testing.TB, dbType string) *sqlx.DB {
+	var conn string
+	switch dbType {
+	case "sqlite3":
+		conn = filepath.Join(t.TempDir(), "test.db")
+
+	case "postgres", "mysql":
+		server := os.Getenv(Env[dbType])
+		if server == "" {
+			skip(t, "%s is not set", Env[dbType])
+		}
+
+		var err error
+		conn, err = createDatabase(t, dbType, server)
+		if err != nil {
+			t.Fatalf("could not create %s test database: %s", dbType, err)
+		}
+
+	default:
+		t.Fatalf("unknown database type %q", dbType)
+	}
+
+	db, err := database.Open(dbType, conn)
+	if err != nil {
+		if dbType == "sqlite3" {
+			// The sqlite3 driver only works when built with cgo.
+			skip(t, "sqlite3 is not available: %s", err)
+		}
+		t.Fatalf("could not connect to %s test database: %s", dbType, err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// skip skips the test, or fails it when running in CI, where every type of
+// database must be tested.
+func skip(t testing.TB, format string, args ...interface{}) {
+	t.Helper()
+	if os.Getenv("CI") != "" {
+		t.Fatalf(format+" (every database must be available in CI)", args...)
+	}
+	t.Skipf(format, args...)
+}
+
+// createDatabase creates a new database on the given server, which is
+// dropped when the test finishes, and returns the connection string for it.
+func createDatabase(t testing.TB, dbType, server string) (string, error) {
+	name := fmt.Sprintf("test_%d", time.Now().UnixNano())
+
+	var conn string
+	switch dbType {
+	case "postgres":
+		if strings.HasPrefix(server, "postgres://") || strings.HasPrefix(server, "postgresql://") {
+			var err error
+			server, err = pq.ParseURL(server)
+			if err != nil {
+				return "", err
+			}
+		}
+		// Later settings override earlier ones.
+		conn = server + " dbname=" + name
+
+	case "mysql":
+		cfg, err := mysql.ParseDSN(server)
+		if err != nil {
+			return "", err
+		}
+		cfg.DBName = name
+		conn = cfg.FormatDSN()
+	}
+
+	admin, err := sqlx.Open(dbType, server)
+	if err != nil {
+		return "", err
+	}
+	if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+		admin.Close()
+		return "", err
+	}
+
+	// Cleanups run in reverse order, so this runs after the connection
+	// to the new database is closed.
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP DATABASE " + name); err != nil {
+			t.Errorf("could not drop %s test database %s: %s", dbType, name, err)
+		}
+		admin.Close()
+	})
+	return conn, nil
+}
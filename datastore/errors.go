@@ -0,0 +1,18 @@
+package datastore
+
+import (
+	"errors"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("datastore: record not found")
+
+	// ErrConflict is returned when an operation would violate a uniqueness
+	// or referential constraint (e.g. a duplicate key).
+	ErrConflict = errors.New("datastore: conflicting record")
+
+	// ErrValidation is returned when the database rejects a record as
+	// invalid (e.g. a NOT NULL or CHECK constraint, or an over-long value).
+	ErrValidation = errors.New("datastore: invalid record")
+)
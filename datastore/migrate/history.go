@@ -0,0 +1,158 @@
+package migrate
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Record is an entry in the migration history table, recording a migration
+// that has been applied to the database.
+type Record struct {
+	Version   int    `db:"version"`
+	Name      string `db:"name"`
+	Checksum  string `db:"checksum"`
+	AppliedAt string `db:"applied_at"`
+}
+
+// history returns the migrations that have been applied to the database,
+// ordered by version, creating the history table if it does not already
+// exist.
+func (m Migrator) history(db *sqlx.DB) ([]*Record, error) {
+	if _, err := db.Exec(historyTable); err != nil {
+		return nil, err
+	}
+
+	records := []*Record{}
+	if err := db.Select(&records, historyListQuery); err != nil {
+		return nil, err
+	}
+
+	// Databases created before the history table existed only have a
+	// version number, so we assume that the first migrations were applied.
+	if len(records) == 0 {
+		if err := m.adoptLegacyVersion(db); err != nil {
+			return nil, err
+		}
+		if err := db.Select(&records, historyListQuery); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.restampChecksums(db, records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// restampChecksums replaces the checksums that older versions recorded,
+// which didn't cover the Down statements (see Migration.upChecksum), with
+// the current ones.  Only records whose Up statements are unchanged are
+// updated, so changed migrations are still detected.
+func (m Migrator) restampChecksums(db *sqlx.DB, records []*Record) error {
+	migrations := m.Migrations()
+	for _, rec := range records {
+		if rec.Version < 1 || rec.Version > len(migrations) {
+			continue
+		}
+
+		mig := migrations[rec.Version-1]
+		if rec.Checksum != mig.upChecksum() {
+			continue
+		}
+		if _, err := db.Exec(m.rebind(historyUpdateChecksumQuery), mig.Checksum(), rec.Version); err != nil {
+			return err
+		}
+		rec.Checksum = mig.Checksum()
+	}
+	return nil
+}
+
+// adoptLegacyVersion converts the single version number that older versions
+// stored in the 'migration_version' table into history records.
+func (m Migrator) adoptLegacyVersion(db *sqlx.DB) (err error) {
+	// If this fails, the table doesn't exist, and there's nothing to do.
+	var version int
+	if err := db.Get(&version, "SELECT version FROM migration_version"); err != nil {
+		return nil
+	}
+
+	var tx *sqlx.Tx
+	tx, err = db.Beginx()
+	if err != nil {
+		return
+	}
+
+	// Automatically rollback/commit if there's an error.
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			tx.Commit()
+		}
+	}()
+
+	migrations := m.Migrations()
+	for i := 0; i < version && i < len(migrations); i++ {
+		if err = m.addRecord(tx, i+1, migrations[i]); err != nil {
+			return
+		}
+	}
+
+	_, err = tx.Exec("DROP TABLE migration_version")
+	return
+}
+
+// addRecord records the given migration as applied.
+func (m Migrator) addRecord(tx *sqlx.Tx, version int, mig *Migration) error {
+	_, err := tx.Exec(m.rebind(historyInsertQuery),
+		version,
+		mig.Name,
+		mig.Checksum(),
+		time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// removeRecord records the given migration as no longer applied.
+func (m Migrator) removeRecord(tx *sqlx.Tx, version int) error {
+	_, err := tx.Exec(m.rebind(historyDeleteQuery), version)
+	return err
+}
+
+const historyTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	 version    INTEGER PRIMARY KEY
+	,name       VARCHAR(255) NOT NULL
+	,checksum   VARCHAR(64) NOT NULL
+	,applied_at VARCHAR(64) NOT NULL
+)
+`
+
+const historyListQuery = `
+SELECT *
+FROM schema_migrations
+ORDER BY version
+`
+
+const historyInsertQuery = `
+INSERT
+INTO schema_migrations (
+     version
+    ,name
+    ,checksum
+    ,applied_at
+)
+VALUES (?, ?, ?, ?)
+`
+
+const historyUpdateChecksumQuery = `
+UPDATE schema_migrations
+SET checksum = ?
+WHERE version = ?
+`
+
+const historyDeleteQuery = `
+DELETE
+FROM schema_migrations
+WHERE version = ?
+`
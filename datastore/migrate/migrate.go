@@ -1,7 +1,11 @@
 package migrate
 
 import (
-	"github.com/BurntSushi/migration"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+
 	"github.com/jmoiron/sqlx"
 )
 
@@ -13,35 +17,220 @@ func (m Migrator) rebind(s string) string {
 	return sqlx.Rebind(sqlx.BindType(m.DbType), s)
 }
 
-// Setup will create all necessary tables and indexes in the database.
-func (m Migrator) Setup(tx migration.LimitedTx) error {
-	stmts := []string{
-		peopleTable,
-	}
+// Migration is a single, reversible change to the database.
+type Migration struct {
+	// Name of the migration, which must be unique.
+	Name string
 
-	for _, stmt := range stmts {
-		if _, err := tx.Exec(stmt); err != nil {
-			return err
-		}
-	}
+	// Statements to apply and revert the migration.  Down statements
+	// should undo the Up statements exactly, and run in the reverse order.
+	Up   []string
+	Down []string
+}
+
+// Checksum returns a checksum of the statements that apply and revert the
+// migration, used to detect migrations that were changed after they were
+// applied.
+func (m *Migration) Checksum() string {
+	h := sha256.New()
+	io.WriteString(h, strings.Join(m.Up, ";\n"))
+	h.Write([]byte{0})
+	io.WriteString(h, strings.Join(m.Down, ";\n"))
+	return hex.EncodeToString(h.Sum(nil))
+}
 
-	return nil
+// upChecksum returns the checksum that older versions recorded, which only
+// covered the Up statements.
+func (m *Migration) upChecksum() string {
+	sum := sha256.Sum256([]byte(strings.Join(m.Up, ";\n")))
+	return hex.EncodeToString(sum[:])
 }
 
-// CreateDefaultPerson will insert a default (empty) list into the database.
-func (m Migrator) CreateDefaultPerson(tx migration.LimitedTx) error {
-	_, err := tx.Exec(createDefaultPerson)
-	return err
+// DbTypes are the database types that migrations support.
+var DbTypes = []string{"sqlite3", "postgres", "mysql"}
+
+// Dialects maps a database type (as in Migrator.DbType) to the SQL for that
+// database.  The entry for the empty string is used for any database type
+// without its own entry.
+type Dialects map[string]string
+
+// sql returns the SQL from d for the migrator's database type.
+func (m Migrator) sql(d Dialects) string {
+	if s, ok := d[m.DbType]; ok {
+		return s
+	}
+	return d[""]
+}
+
+// Migrations returns all migrations, in the order they are applied.  The
+// version of a migration is its (1-based) position in this list, so new
+// migrations must only ever be added to the end.
+//
+// Every migration must work on all supported databases (SQLite, PostgreSQL
+// and MySQL); use Dialects for statements that need different SQL on each.
+func (m Migrator) Migrations() []*Migration {
+	return []*Migration{
+		{
+			Name: "create_people",
+			Up:   []string{m.sql(peopleTable)},
+			Down: []string{dropPeopleTable},
+		},
+		{
+			Name: "create_default_person",
+			Up:   []string{createDefaultPerson},
+			Down: []string{deleteDefaultPerson},
+		},
+		{
+			Name: "create_sessions",
+			Up:   []string{sessionsTable},
+			Down: []string{dropSessionsTable},
+		},
+		{
+			Name: "create_users",
+			Up:   []string{m.sql(usersTable)},
+			Down: []string{dropUsersTable},
+		},
+		{
+			Name: "create_api_tokens",
+			Up:   []string{m.sql(apiTokensTable)},
+			Down: []string{dropAPITokensTable},
+		},
+		{
+			Name: "create_user_roles",
+			Up:   []string{m.sql(userRolesTable)},
+			Down: []string{dropUserRolesTable},
+		},
+	}
 }
 
-const peopleTable = `
+var peopleTable = Dialects{
+	"sqlite3": `
 CREATE TABLE IF NOT EXISTS people (
 	 id   INTEGER PRIMARY KEY AUTOINCREMENT
 	,name TEXT NOT NULL
 )
+`,
+	"postgres": `
+CREATE TABLE IF NOT EXISTS people (
+	 id   BIGSERIAL PRIMARY KEY
+	,name TEXT NOT NULL
+)
+`,
+	"mysql": `
+CREATE TABLE IF NOT EXISTS people (
+	 id   BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY
+	,name VARCHAR(255) NOT NULL
+)
+`,
+}
+
+const dropPeopleTable = `
+DROP TABLE people
 `
 
 const createDefaultPerson = `
 INSERT INTO people(name)
-VALUES ("Joe Smith")
+VALUES ('Joe Smith')
+`
+
+const deleteDefaultPerson = `
+DELETE FROM people
+WHERE id = 1 AND name = 'Joe Smith'
+`
+
+const sessionsTable = `
+CREATE TABLE IF NOT EXISTS sessions (
+	 id         VARCHAR(64) PRIMARY KEY
+	,data       TEXT NOT NULL
+	,expires_at BIGINT NOT NULL
+)
+`
+
+const dropSessionsTable = `
+DROP TABLE sessions
+`
+
+var usersTable = Dialects{
+	"sqlite3": `
+CREATE TABLE IF NOT EXISTS users (
+	 id            INTEGER PRIMARY KEY AUTOINCREMENT
+	,email         TEXT NOT NULL UNIQUE
+	,password_hash TEXT NOT NULL
+)
+`,
+	"postgres": `
+CREATE TABLE IF NOT EXISTS users (
+	 id            BIGSERIAL PRIMARY KEY
+	,email         TEXT NOT NULL UNIQUE
+	,password_hash TEXT NOT NULL
+)
+`,
+	"mysql": `
+CREATE TABLE IF NOT EXISTS users (
+	 id            BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY
+	,email         VARCHAR(255) NOT NULL UNIQUE
+	,password_hash VARCHAR(255) NOT NULL
+)
+`,
+}
+
+const dropUsersTable = `
+DROP TABLE users
+`
+
+var apiTokensTable = Dialects{
+	"sqlite3": `
+CREATE TABLE IF NOT EXISTS api_tokens (
+	 id         INTEGER PRIMARY KEY AUTOINCREMENT
+	,user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE
+	,name       TEXT NOT NULL
+	,token_hash TEXT NOT NULL UNIQUE
+	,created_at BIGINT NOT NULL
+)
+`,
+	"postgres": `
+CREATE TABLE IF NOT EXISTS api_tokens (
+	 id         BIGSERIAL PRIMARY KEY
+	,user_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE
+	,name       TEXT NOT NULL
+	,token_hash TEXT NOT NULL UNIQUE
+	,created_at BIGINT NOT NULL
+)
+`,
+	"mysql": `
+CREATE TABLE IF NOT EXISTS api_tokens (
+	 id         BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY
+	,user_id    BIGINT NOT NULL
+	,name       VARCHAR(255) NOT NULL
+	,token_hash VARCHAR(64) NOT NULL UNIQUE
+	,created_at BIGINT NOT NULL
+	,FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+)
+`,
+}
+
+const dropAPITokensTable = `
+DROP TABLE api_tokens
+`
+
+var userRolesTable = Dialects{
+	"": `
+CREATE TABLE IF NOT EXISTS user_roles (
+	 user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE
+	,role    VARCHAR(64) NOT NULL
+	,PRIMARY KEY (user_id, role)
+)
+`,
+	"mysql": `
+CREATE TABLE IF NOT EXISTS user_roles (
+	 user_id BIGINT NOT NULL
+	,role    VARCHAR(64) NOT NULL
+	,PRIMARY KEY (user_id, role)
+	,FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+)
+`,
+}
+
+const dropUserRolesTable = `
+DROP TABLE user_roles
 `
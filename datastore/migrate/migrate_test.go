@@ -0,0 +1,135 @@
+package migrate_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore/dbtest"
+	"github.com/andrew-d/go-webapp-skeleton/datastore/migrate"
+)
+
+func TestMigrations(t *testing.T) {
+	for _, dbType := range migrate.DbTypes {
+		t.Run(dbType, func(t *testing.T) {
+			db := dbtest.Open(t, dbType)
+			m := migrate.Migrator{DbType: dbType}
+			n := len(m.Migrations())
+
+			// Apply the migrations one at a time, checking that each
+			// one can be reverted and applied again.
+			for version := 1; version <= n; version++ {
+				if err := m.To(db, version); err != nil {
+					t.Fatalf("To(%d): %s", version, err)
+				}
+				if err := m.Redo(db); err != nil {
+					t.Fatalf("Redo at version %d: %s", version, err)
+				}
+				checkVersion(t, m, db, version)
+			}
+
+			statuses, err := m.Status(db)
+			if err != nil {
+				t.Fatalf("Status: %s", err)
+			}
+			for _, s := range statuses {
+				if !s.Applied() || s.Changed() {
+					t.Errorf("migration %d: applied=%t changed=%t, want applied and unchanged",
+						s.Version, s.Applied(), s.Changed())
+				}
+			}
+
+			for version := n; version > 0; version-- {
+				if err := m.Down(db); err != nil {
+					t.Fatalf("Down from version %d: %s", version, err)
+				}
+				checkVersion(t, m, db, version-1)
+			}
+			if err := m.Down(db); err == nil {
+				t.Errorf("Down at version 0 succeeded, want an error")
+			}
+
+			// Reverting every migration must leave nothing behind that
+			// stops them from being applied again.
+			if err := m.Up(db); err != nil {
+				t.Fatalf("Up after reverting everything: %s", err)
+			}
+			checkVersion(t, m, db, n)
+		})
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	base := &migrate.Migration{
+		Name: "test",
+		Up:   []string{"CREATE TABLE a (id INTEGER)", "CREATE TABLE b (id INTEGER)"},
+		Down: []string{"DROP TABLE b", "DROP TABLE a"},
+	}
+
+	tests := []struct {
+		name string
+		mig  *migrate.Migration
+		same bool
+	}{
+		{"identical", &migrate.Migration{Name: "renamed", Up: base.Up, Down: base.Down}, true},
+		{"up changed", &migrate.Migration{Up: base.Up[:1], Down: base.Down}, false},
+		{"down changed", &migrate.Migration{Up: base.Up, Down: base.Down[:1]}, false},
+		{"statement moved", &migrate.Migration{Up: base.Up[:1], Down: append([]string{base.Up[1]}, base.Down...)}, false},
+	}
+
+	for _, tt := range tests {
+		if same := tt.mig.Checksum() == base.Checksum(); same != tt.same {
+			t.Errorf("%s: checksums equal = %t, want %t", tt.name, same, tt.same)
+		}
+	}
+}
+
+// TestRestampChecksums checks that checksums recorded by older versions,
+// which only covered the Up statements, are replaced with the current ones.
+func TestRestampChecksums(t *testing.T) {
+	for _, dbType := range migrate.DbTypes {
+		t.Run(dbType, func(t *testing.T) {
+			db := dbtest.Open(t, dbType)
+			m := migrate.Migrator{DbType: dbType}
+			if err := m.Up(db); err != nil {
+				t.Fatalf("Up: %s", err)
+			}
+
+			// Record the old checksums, except for the first
+			// migration, which looks as if it was changed.
+			for i, mig := range m.Migrations() {
+				sum := sha256.Sum256([]byte(strings.Join(mig.Up, ";\n")))
+				checksum := hex.EncodeToString(sum[:])
+				if i == 0 {
+					checksum = "changed"
+				}
+				_, err := db.Exec(db.Rebind("UPDATE schema_migrations SET checksum = ? WHERE version = ?"), checksum, i+1)
+				if err != nil {
+					t.Fatalf("could not set checksum: %s", err)
+				}
+			}
+
+			statuses, err := m.Status(db)
+			if err != nil {
+				t.Fatalf("Status: %s", err)
+			}
+			for _, s := range statuses {
+				if want := s.Version == 1; s.Changed() != want {
+					t.Errorf("migration %d: changed=%t, want %t", s.Version, s.Changed(), want)
+				}
+			}
+		})
+	}
+}
+
+func checkVersion(t *testing.T, m migrate.Migrator, db *sqlx.DB, want int) {
+	t.Helper()
+	if version, err := m.Version(db); err != nil {
+		t.Fatalf("Version: %s", err)
+	} else if version != want {
+		t.Fatalf("Version = %d, want %d", version, want)
+	}
+}
@@ -0,0 +1,212 @@
+package migrate
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Status describes a single migration, and whether it has been applied to
+// the database.
+type Status struct {
+	Version int
+
+	// The migration, or nil if the database has a migration applied that
+	// this program doesn't know about.
+	Migration *Migration
+
+	// The history record for the migration, or nil if it has not been
+	// applied.
+	Record *Record
+}
+
+// Applied returns whether the migration has been applied to the database.
+func (s *Status) Applied() bool {
+	return s.Record != nil
+}
+
+// Changed returns whether the migration was changed after it was applied to
+// the database.
+func (s *Status) Changed() bool {
+	return s.Record != nil && s.Migration != nil && s.Record.Checksum != s.Migration.Checksum()
+}
+
+// Status returns the status of every migration, ordered by version.
+func (m Migrator) Status(db *sqlx.DB) ([]*Status, error) {
+	records, err := m.history(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []*Status
+	for i, mig := range m.Migrations() {
+		statuses = append(statuses, &Status{Version: i + 1, Migration: mig})
+	}
+	for _, rec := range records {
+		if rec.Version >= 1 && rec.Version <= len(statuses) {
+			statuses[rec.Version-1].Record = rec
+		} else {
+			statuses = append(statuses, &Status{Version: rec.Version, Record: rec})
+		}
+	}
+
+	return statuses, nil
+}
+
+// Version returns the current version of the database, which is the version
+// of the latest migration that has been applied, or 0 if there are none.
+func (m Migrator) Version(db *sqlx.DB) (int, error) {
+	statuses, err := m.Status(db)
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for _, s := range statuses {
+		if s.Applied() {
+			version = s.Version
+		}
+	}
+	return version, nil
+}
+
+// Check returns an error if any migration that was applied to the database
+// has since been changed, or is not known to this program.
+func (m Migrator) Check(db *sqlx.DB) error {
+	statuses, err := m.Status(db)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		switch {
+		case s.Migration == nil:
+			return fmt.Errorf("database has unknown migration %d (%s) applied",
+				s.Version, s.Record.Name)
+		case s.Changed():
+			return fmt.Errorf("migration %d (%s) has changed since it was applied",
+				s.Version, s.Migration.Name)
+		}
+	}
+	return nil
+}
+
+// Up applies all migrations that have not yet been applied.
+func (m Migrator) Up(db *sqlx.DB) error {
+	return m.To(db, len(m.Migrations()))
+}
+
+// Down reverts the latest migration.
+func (m Migrator) Down(db *sqlx.DB) error {
+	version, err := m.Version(db)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		return fmt.Errorf("no migrations have been applied")
+	}
+	return m.To(db, version-1)
+}
+
+// Redo reverts and then re-applies the latest migration.
+func (m Migrator) Redo(db *sqlx.DB) error {
+	version, err := m.Version(db)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		return fmt.Errorf("no migrations have been applied")
+	}
+	if err := m.To(db, version-1); err != nil {
+		return err
+	}
+	return m.To(db, version)
+}
+
+// To applies or reverts migrations until the database is at the given
+// version.  It refuses to run if Check fails.
+func (m Migrator) To(db *sqlx.DB, target int) error {
+	if !m.supported() {
+		return fmt.Errorf("unsupported database type %q", m.DbType)
+	}
+
+	migrations := m.Migrations()
+	if target < 0 || target > len(migrations) {
+		return fmt.Errorf("invalid version %d (must be between 0 and %d)", target, len(migrations))
+	}
+
+	if err := m.Check(db); err != nil {
+		return err
+	}
+
+	version, err := m.Version(db)
+	if err != nil {
+		return err
+	}
+
+	for ; version < target; version++ {
+		mig := migrations[version]
+		log.Printf("info: applying migration version=%d name=%q", version+1, mig.Name)
+		if err := m.apply(db, version+1, mig, true); err != nil {
+			return fmt.Errorf("applying migration %d (%s): %s", version+1, mig.Name, err)
+		}
+	}
+	for ; version > target; version-- {
+		mig := migrations[version-1]
+		log.Printf("info: reverting migration version=%d name=%q", version, mig.Name)
+		if err := m.apply(db, version, mig, false); err != nil {
+			return fmt.Errorf("reverting migration %d (%s): %s", version, mig.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// supported returns whether migrations can be run on the migrator's database
+// type.
+func (m Migrator) supported() bool {
+	for _, t := range DbTypes {
+		if m.DbType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// apply runs the statements for a single migration, and updates the history
+// table, in a single transaction.
+func (m Migrator) apply(db *sqlx.DB, version int, mig *Migration, up bool) (err error) {
+	var tx *sqlx.Tx
+
+	tx, err = db.Beginx()
+	if err != nil {
+		return
+	}
+
+	// Automatically rollback/commit if there's an error.
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	stmts := mig.Up
+	if !up {
+		stmts = mig.Down
+	}
+	for _, stmt := range stmts {
+		if _, err = tx.Exec(stmt); err != nil {
+			return
+		}
+	}
+
+	if up {
+		err = m.addRecord(tx, version, mig)
+	} else {
+		err = m.removeRecord(tx, version)
+	}
+	return
+}
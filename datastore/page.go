@@ -0,0 +1,112 @@
+package datastore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidCursor is returned when a pagination cursor can't be
+	// decoded, or doesn't match the requested sort order.
+	ErrInvalidCursor = errors.New("datastore: invalid cursor")
+
+	// ErrInvalidSort is returned when records are sorted by a field that
+	// can't be sorted on.
+	ErrInvalidSort = errors.New("datastore: invalid sort field")
+)
+
+// Cursor marks a position in a list of records.  Cursors are handed to
+// clients in an opaque, encoded form (see Encode and DecodeCursor).
+type Cursor struct {
+	// ID of the record that the cursor is positioned at.
+	ID int64 `json:"id"`
+
+	// Values of the record's other sort fields, in sort order.
+	Values []interface{} `json:"v,omitempty"`
+
+	// Whether the cursor is used to retrieve the records before (rather than
+	// after) the record with the given ID.
+	Before bool `json:"before,omitempty"`
+}
+
+// Encode returns the opaque string form of the cursor.
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor that was previously returned from Encode.  The
+// empty string decodes to a nil cursor.
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	c := &Cursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// ListOptions controls which page of records is retrieved by a List method.
+type ListOptions struct {
+	// Maximum number of records to retrieve.
+	Limit int
+
+	// Position to start listing from.  If nil, the first page is returned.
+	Cursor *Cursor
+
+	// Fields to sort the records by.  If empty, records are sorted by
+	// descending ID.
+	Sort []SortField
+
+	// Whether to count the total number of records.
+	IncludeTotal bool
+}
+
+// SortField is a single field that records are sorted by.
+type SortField struct {
+	Name string
+	Desc bool
+}
+
+// ParseSort parses a comma-separated list of field names into sort fields.  A
+// field name prefixed with '-' is sorted in descending order.  The field names
+// are not checked here; that is up to the datastore.
+func ParseSort(s string) []SortField {
+	var fields []SortField
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+
+		var desc bool
+		if strings.HasPrefix(name, "-") {
+			name, desc = name[1:], true
+		}
+		if name == "" {
+			continue
+		}
+
+		fields = append(fields, SortField{Name: name, Desc: desc})
+	}
+	return fields
+}
+
+// Page contains information about a page of records retrieved by a List
+// method.
+type Page struct {
+	// Cursors for the next and previous pages, or nil if there is no such
+	// page.
+	Next *Cursor
+	Prev *Cursor
+
+	// Total number of records, or -1 if it was not requested.
+	Total int
+}
@@ -6,23 +6,41 @@ import (
 	"github.com/andrew-d/go-webapp-skeleton/model"
 )
 
+// PeopleFilter restricts which people are returned from ListPeople.
+type PeopleFilter struct {
+	// Only return people whose name contains this string (ignoring case).
+	// If NamePrefix is set, the name must instead start with this string.
+	Name       string
+	NamePrefix bool
+
+	// Only return people with one of these IDs.
+	IDs []int64
+}
+
 type PeopleStore interface {
-	// ListPeople retrieves all people from the database, possibly with an
-	// offset or limit provided.
-	ListPeople(limit, offset int) ([]*model.Person, error)
+	// ListPeople retrieves a page of people matching the given filter from
+	// the database, along with information about the surrounding pages.
+	// People can be sorted by "id" and "name".
+	ListPeople(filter *PeopleFilter, opts *ListOptions) ([]*model.Person, *Page, error)
 
-	// GetPerson retrieves a person from the datastore for the given ID.
+	// GetPerson retrieves a person from the datastore for the given ID.  It
+	// returns ErrNotFound if no such person exists.
 	GetPerson(id int64) (*model.Person, error)
 
 	// CreatePerson saves a new person in the datastore.
 	CreatePerson(person *model.Person) error
 
-	// DeletePerson removes a person from the datastore.
+	// UpdatePerson saves changes to an existing person in the datastore.  It
+	// returns ErrNotFound if no such person exists.
+	UpdatePerson(person *model.Person) error
+
+	// DeletePerson removes a person from the datastore.  It returns
+	// ErrNotFound if no such person exists.
 	DeletePerson(id int64) error
 }
 
-func ListPeople(c context.Context, limit, offset int) ([]*model.Person, error) {
-	return FromContext(c).ListPeople(limit, offset)
+func ListPeople(c context.Context, filter *PeopleFilter, opts *ListOptions) ([]*model.Person, *Page, error) {
+	return FromContext(c).ListPeople(filter, opts)
 }
 
 func GetPerson(c context.Context, id int64) (*model.Person, error) {
@@ -33,6 +51,10 @@ func CreatePerson(c context.Context, person *model.Person) error {
 	return FromContext(c).CreatePerson(person)
 }
 
+func UpdatePerson(c context.Context, person *model.Person) error {
+	return FromContext(c).UpdatePerson(person)
+}
+
 func DeletePerson(c context.Context, id int64) error {
 	return FromContext(c).DeletePerson(id)
 }
@@ -0,0 +1,31 @@
+package datastore
+
+import (
+	"golang.org/x/net/context"
+)
+
+type RoleStore interface {
+	// ListUserRoles retrieves the names of the roles assigned to the given
+	// user, in alphabetical order.
+	ListUserRoles(userID int64) ([]string, error)
+
+	// AddUserRole assigns a role to the given user.  It returns ErrConflict
+	// if the user already has the role.
+	AddUserRole(userID int64, role string) error
+
+	// RemoveUserRole removes a role from the given user.  It returns
+	// ErrNotFound if the user doesn't have the role.
+	RemoveUserRole(userID int64, role string) error
+}
+
+func ListUserRoles(c context.Context, userID int64) ([]string, error) {
+	return FromContext(c).ListUserRoles(userID)
+}
+
+func AddUserRole(c context.Context, userID int64, role string) error {
+	return FromContext(c).AddUserRole(userID, role)
+}
+
+func RemoveUserRole(c context.Context, userID int64, role string) error {
+	return FromContext(c).RemoveUserRole(userID, role)
+}
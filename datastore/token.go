@@ -0,0 +1,40 @@
+package datastore
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+type TokenStore interface {
+	// ListTokens retrieves all API tokens belonging to the given user,
+	// oldest first.
+	ListTokens(userID int64) ([]*model.Token, error)
+
+	// GetTokenByHash retrieves the API token with the given hash.  It
+	// returns ErrNotFound if no such token exists.
+	GetTokenByHash(hash string) (*model.Token, error)
+
+	// CreateToken saves a new API token in the datastore.
+	CreateToken(token *model.Token) error
+
+	// DeleteToken removes the given user's API token from the datastore.  It
+	// returns ErrNotFound if the user has no such token.
+	DeleteToken(userID, id int64) error
+}
+
+func ListTokens(c context.Context, userID int64) ([]*model.Token, error) {
+	return FromContext(c).ListTokens(userID)
+}
+
+func GetTokenByHash(c context.Context, hash string) (*model.Token, error) {
+	return FromContext(c).GetTokenByHash(hash)
+}
+
+func CreateToken(c context.Context, token *model.Token) error {
+	return FromContext(c).CreateToken(token)
+}
+
+func DeleteToken(c context.Context, userID, id int64) error {
+	return FromContext(c).DeleteToken(userID, id)
+}
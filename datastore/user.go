@@ -0,0 +1,33 @@
+package datastore
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+type UserStore interface {
+	// GetUser retrieves a user from the datastore for the given ID.  It
+	// returns ErrNotFound if no such user exists.
+	GetUser(id int64) (*model.User, error)
+
+	// GetUserByEmail retrieves the user with the given (normalized) email
+	// address.  It returns ErrNotFound if no such user exists.
+	GetUserByEmail(email string) (*model.User, error)
+
+	// CreateUser saves a new user in the datastore.  It returns ErrConflict
+	// if a user with the same email address already exists.
+	CreateUser(user *model.User) error
+}
+
+func GetUser(c context.Context, id int64) (*model.User, error) {
+	return FromContext(c).GetUser(id)
+}
+
+func GetUserByEmail(c context.Context, email string) (*model.User, error) {
+	return FromContext(c).GetUserByEmail(email)
+}
+
+func CreateUser(c context.Context, user *model.User) error {
+	return FromContext(c).CreateUser(user)
+}
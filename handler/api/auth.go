@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/handler/apierror"
+	"github.com/andrew-d/go-webapp-skeleton/middleware"
+)
+
+// Login accepts a request to log in with an email address and password.  On
+// success, the user is returned, the session cookie is set, and a CSRF token
+// for later requests is returned in the 'X-CSRF-Token' header.
+//
+//     POST /api/login
+//
+func Login(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	in := struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}{}
+	if !decodeJSON(ctx, w, r, &in) {
+		return
+	}
+
+	user, err := auth.Authenticate(ctx, in.Email, in.Password)
+	if err == auth.ErrInvalidCredentials {
+		writeError(ctx, w, apierror.New(http.StatusUnauthorized, err.Error()))
+		return
+	}
+	if err != nil {
+		log.Printf("error: error authenticating user err=%q", err)
+		writeError(ctx, w, apierror.FromError(err))
+		return
+	}
+
+	// Requests that use the session cookie from now on need a CSRF token.
+	auth.Login(ctx, user)
+	w.Header().Set(middleware.CSRFHeader, middleware.CSRFToken(ctx))
+	json.NewEncoder(w).Encode(user)
+}
+
+// Logout accepts a request to end the current session.
+//
+//     POST /api/logout
+//
+func Logout(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	auth.Logout(ctx)
+	w.WriteHeader(http.StatusNoContent)
+}
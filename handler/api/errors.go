@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/handler/apierror"
+	"github.com/andrew-d/go-webapp-skeleton/middleware"
+)
+
+// NotFound is the fallback handler for API routes that don't exist.
+func NotFound(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	writeError(ctx, w, apierror.New(http.StatusNotFound, "not found"))
+}
+
+// writeError sends the given error to the client, tagged with the ID of the
+// current request.
+func writeError(ctx context.Context, w http.ResponseWriter, e *apierror.Error) {
+	e.RequestID = middleware.GetRequestID(ctx)
+	apierror.Write(w, e)
+}
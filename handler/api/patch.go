@@ -0,0 +1,53 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// mergePatch applies the JSON merge patch (RFC 7396) in patch to the JSON
+// representation of orig, and then decodes the result into out.
+func mergePatch(orig interface{}, patch interface{}, out interface{}) error {
+	data, err := json.Marshal(orig)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(applyMergePatch(doc, patch))
+	if err != nil {
+		return err
+	}
+
+	// Reject patches that add fields that don't exist.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(out)
+}
+
+// applyMergePatch implements the MergePatch algorithm from RFC 7396, section 2.
+func applyMergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+		} else {
+			t[name] = applyMergePatch(t[name], value)
+		}
+	}
+
+	return t
+}
@@ -11,26 +11,45 @@ import (
 
 	"github.com/andrew-d/go-webapp-skeleton/datastore"
 	"github.com/andrew-d/go-webapp-skeleton/handler"
+	"github.com/andrew-d/go-webapp-skeleton/handler/apierror"
 	"github.com/andrew-d/go-webapp-skeleton/model"
+	"github.com/andrew-d/go-webapp-skeleton/validate"
 )
 
-// ListPeople accepts a request to retrieve a list of people.
+// ListPeople accepts a request to retrieve a page of people.  Links to the
+// surrounding pages are given in the 'Link' header, and the total number of
+// matching people in the 'X-Total-Count' header, if asked for.
 //
-//     GET /api/people
+//     GET /api/people?limit=:limit&cursor=:cursor&total=true
+//     GET /api/people?q=:name&sort=:fields
+//     GET /api/people?id=:id,:id,...
 //
 func ListPeople(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	var (
-		limit  = handler.ToLimit(r)
-		offset = handler.ToOffset(r)
-	)
+	cursor, err := handler.ToCursor(r)
+	if err != nil {
+		writeError(ctx, w, apierror.New(http.StatusBadRequest, "invalid cursor"))
+		return
+	}
 
-	people, err := datastore.ListPeople(ctx, limit, offset)
+	filter, err := handler.ToPeopleFilter(r)
+	if err != nil {
+		writeError(ctx, w, apierror.New(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	people, page, err := datastore.ListPeople(ctx, filter, &datastore.ListOptions{
+		Limit:        handler.ToLimit(r),
+		Cursor:       cursor,
+		Sort:         handler.ToSort(r),
+		IncludeTotal: handler.ToIncludeTotal(r),
+	})
 	if err != nil {
 		log.Printf("error: error listing people err=%q", err)
-		w.WriteHeader(http.StatusNotFound)
+		writeError(ctx, w, apierror.FromError(err))
 		return
 	}
 
+	handler.SetPageHeaders(w, r, page)
 	json.NewEncoder(w).Encode(people)
 }
 
@@ -45,14 +64,14 @@ func GetPerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(ctx, w, apierror.New(http.StatusBadRequest, "invalid person ID"))
 		return
 	}
 
 	person, err := datastore.GetPerson(ctx, id)
 	if err != nil {
 		log.Printf("error: error getting person err=%q", err)
-		w.WriteHeader(http.StatusNotFound)
+		writeError(ctx, w, apierror.FromError(err))
 		return
 	}
 
@@ -70,14 +89,14 @@ func DeletePerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(ctx, w, apierror.New(http.StatusBadRequest, "invalid person ID"))
 		return
 	}
 
 	err = datastore.DeletePerson(ctx, id)
 	if err != nil {
 		log.Printf("error: error deleting person err=%q", err)
-		w.WriteHeader(http.StatusNotFound)
+		writeError(ctx, w, apierror.FromError(err))
 		return
 	}
 
@@ -90,29 +109,128 @@ func DeletePerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 //
 func CreatePerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	// Unmarshal the person from the payload
-	defer r.Body.Close()
 	in := struct {
 		Name string `json:"name"`
 	}{}
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !decodeJSON(ctx, w, r, &in) {
 		return
 	}
 
-	// Validate input
-	if len(in.Name) < 1 {
-		http.Error(w, "no name given", http.StatusBadRequest)
+	// Create our 'normal' model, and validate it.
+	person := &model.Person{Name: in.Name}
+	if err := validate.Struct(person); err != nil {
+		writeError(ctx, w, apierror.FromError(err))
 		return
 	}
 
-	// Create our 'normal' model.
-	person := &model.Person{Name: in.Name}
 	err := datastore.CreatePerson(ctx, person)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("error: error saving person err=%q", err)
+		writeError(ctx, w, apierror.FromError(err))
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(person)
 }
+
+// UpdatePerson accepts a request to replace an existing person.
+//
+//     PUT /api/people/:person
+//
+func UpdatePerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	var (
+		idStr = pat.Param(ctx, "person")
+	)
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(ctx, w, apierror.New(http.StatusBadRequest, "invalid person ID"))
+		return
+	}
+
+	// Unmarshal the person from the payload
+	in := struct {
+		Name string `json:"name"`
+	}{}
+	if !decodeJSON(ctx, w, r, &in) {
+		return
+	}
+
+	person, err := datastore.GetPerson(ctx, id)
+	if err != nil {
+		log.Printf("error: error getting person err=%q", err)
+		writeError(ctx, w, apierror.FromError(err))
+		return
+	}
+
+	person.Name = in.Name
+	if err := validate.Struct(person); err != nil {
+		writeError(ctx, w, apierror.FromError(err))
+		return
+	}
+
+	err = datastore.UpdatePerson(ctx, person)
+	if err != nil {
+		log.Printf("error: error saving person err=%q", err)
+		writeError(ctx, w, apierror.FromError(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(person)
+}
+
+// PatchPerson accepts a request to partially update an existing person.  The
+// payload is treated as a JSON merge patch (RFC 7396).
+//
+//     PATCH /api/people/:person
+//
+func PatchPerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	var (
+		idStr = pat.Param(ctx, "person")
+	)
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(ctx, w, apierror.New(http.StatusBadRequest, "invalid person ID"))
+		return
+	}
+
+	// Read the patch from the payload
+	var patch interface{}
+	if !decodeJSON(ctx, w, r, &patch) {
+		return
+	}
+
+	person, err := datastore.GetPerson(ctx, id)
+	if err != nil {
+		log.Printf("error: error getting person err=%q", err)
+		writeError(ctx, w, apierror.FromError(err))
+		return
+	}
+
+	// Apply the patch to the current representation of the person.
+	patched := &model.Person{}
+	if err := mergePatch(person, patch, patched); err != nil {
+		writeError(ctx, w, apierror.New(http.StatusBadRequest, "invalid patch: "+err.Error()))
+		return
+	}
+
+	// The ID comes from the URL, and can't be changed by the patch.
+	patched.ID = person.ID
+
+	// Validate input
+	if err := validate.Struct(patched); err != nil {
+		writeError(ctx, w, apierror.FromError(err))
+		return
+	}
+
+	err = datastore.UpdatePerson(ctx, patched)
+	if err != nil {
+		log.Printf("error: error saving person err=%q", err)
+		writeError(ctx, w, apierror.FromError(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(patched)
+}
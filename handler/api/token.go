@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"goji.io/pat"
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+	"github.com/andrew-d/go-webapp-skeleton/handler/apierror"
+)
+
+// ListTokens accepts a request to retrieve the current user's API tokens.
+// The tokens themselves are never returned.
+//
+//     GET /api/tokens
+//
+func ListTokens(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	user := auth.FromContext(ctx)
+
+	tokens, err := datastore.ListTokens(ctx, user.ID)
+	if err != nil {
+		log.Printf("error: error listing tokens err=%q", err)
+		writeError(ctx, w, apierror.FromError(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// CreateToken accepts a request to create a new API token for the current
+// user.  The token is only returned in this response.  Tokens can't be used
+// to create more tokens.
+//
+//     POST /api/tokens
+//
+func CreateToken(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if auth.TokenFromContext(ctx) != nil {
+		writeError(ctx, w, apierror.New(http.StatusForbidden, "API tokens can't be used to create tokens"))
+		return
+	}
+
+	in := struct {
+		Name string `json:"name"`
+	}{}
+	if !decodeJSON(ctx, w, r, &in) {
+		return
+	}
+
+	raw, token, err := auth.NewToken(ctx, auth.FromContext(ctx), in.Name)
+	if err != nil {
+		log.Printf("error: error creating token err=%q", err)
+		writeError(ctx, w, apierror.FromError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		ID        int64  `json:"id"`
+		Name      string `json:"name"`
+		CreatedAt int64  `json:"created_at"`
+		Token     string `json:"token"`
+	}{token.ID, token.Name, token.CreatedAt, raw})
+}
+
+// DeleteToken accepts a request to revoke one of the current user's API
+// tokens.
+//
+//     DELETE /api/tokens/:token
+//
+func DeleteToken(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	var (
+		idStr = pat.Param(ctx, "token")
+		user  = auth.FromContext(ctx)
+	)
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(ctx, w, apierror.New(http.StatusBadRequest, "invalid token ID"))
+		return
+	}
+
+	err = datastore.DeleteToken(ctx, user.ID, id)
+	if err != nil {
+		log.Printf("error: error deleting token err=%q", err)
+		writeError(ctx, w, apierror.FromError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/handler"
+	"github.com/andrew-d/go-webapp-skeleton/handler/apierror"
+)
+
+// decodeJSON decodes the JSON request body into v.  If the body can't be
+// decoded, an error is sent to the client and false is returned.
+func decodeJSON(ctx context.Context, w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := handler.DecodeJSON(w, r, v)
+	switch {
+	case err == nil:
+		return true
+	case err == handler.ErrBodyTooLarge:
+		writeError(ctx, w, apierror.New(http.StatusRequestEntityTooLarge, err.Error()))
+	case err == handler.ErrMultipleJSONValues:
+		writeError(ctx, w, apierror.New(http.StatusBadRequest, err.Error()))
+	default:
+		writeError(ctx, w, apierror.New(http.StatusBadRequest, "invalid JSON: "+err.Error()))
+	}
+	return false
+}
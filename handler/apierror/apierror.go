@@ -0,0 +1,128 @@
+// Package apierror provides the JSON error envelope that is returned by all
+// API routes:
+//
+//     {
+//         "error": {
+//             "code": "not_found",
+//             "message": "record not found",
+//             "request_id": "host.example.com/random-000001",
+//             "details": [...]
+//         }
+//     }
+//
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+	"github.com/andrew-d/go-webapp-skeleton/handler"
+	"github.com/andrew-d/go-webapp-skeleton/validate"
+)
+
+// Detail contains additional information about an error, optionally tied to
+// a single field of the request.
+type Detail struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error is a single API error, as returned to the client.
+type Error struct {
+	// HTTP status code that is sent with this error.
+	Status int `json:"-"`
+
+	Code      string   `json:"code"`
+	Message   string   `json:"message"`
+	RequestID string   `json:"request_id,omitempty"`
+	Details   []Detail `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// New creates an error with the given status and message.  The code is
+// derived from the status.
+func New(status int, message string) *Error {
+	return &Error{
+		Status:  status,
+		Code:    Code(status),
+		Message: message,
+	}
+}
+
+// FromError creates an error from an error returned by the datastore or the
+// validate package; each validation problem is returned as a detail.  Errors
+// that aren't one of the datastore package's sentinel errors become a generic
+// internal server error, so that no internal details leak to the client.
+func FromError(err error) *Error {
+	status := handler.ErrorStatus(err)
+
+	if verrs, ok := err.(validate.Errors); ok {
+		e := New(status, "validation failed")
+		for _, fe := range verrs {
+			e.Details = append(e.Details, Detail{Field: fe.Field, Message: fe.Message})
+		}
+		return e
+	}
+
+	var message string
+	switch err {
+	case datastore.ErrInvalidCursor:
+		message = "invalid cursor"
+	case datastore.ErrInvalidSort:
+		message = "invalid sort field"
+	case datastore.ErrNotFound:
+		message = "record not found"
+	case datastore.ErrConflict:
+		message = "record conflicts with an existing record"
+	case datastore.ErrValidation:
+		message = "record is invalid"
+	default:
+		message = "internal server error"
+	}
+
+	return New(status, message)
+}
+
+// Code returns the machine-readable error code for the given HTTP status.
+func Code(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusMethodNotAllowed:
+		return "method_not_allowed"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusUnsupportedMediaType:
+		return "unsupported_media_type"
+	case http.StatusUnprocessableEntity:
+		return "validation_failed"
+	case http.StatusInternalServerError:
+		return "internal_error"
+	}
+
+	if status >= 500 {
+		return "internal_error"
+	}
+	return "error"
+}
+
+// Write sends the given error to the client, wrapped in the error envelope.
+func Write(w http.ResponseWriter, e *Error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(struct {
+		Error *Error `json:"error"`
+	}{e})
+}
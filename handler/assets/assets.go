@@ -0,0 +1,206 @@
+// Package assets serves static files, such as stylesheets and scripts, from
+// a file system: the files embedded in the binary (see static.FS), a
+// directory on disk (os.DirFS), or an embed.FS.
+//
+// Files can be requested by their plain name, or by a fingerprinted name
+// that includes a hash of their contents (see Handler.Fingerprint), which is
+// looked up in a manifest written at build time if there is one (see
+// ManifestName).
+// Fingerprinted names change whenever the file does, so responses for them
+// can be cached forever; other responses must be revalidated on every use.
+// Files are sent compressed to clients that accept it (see Encodings), and
+// range requests are supported.
+package assets
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"goji.io"
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/middleware"
+)
+
+// Handler serves the files in a file system.
+type Handler struct {
+	// Prefix is the URL path that files are served under, e.g. "/static/".
+	// It is stripped from request paths to get the name of a file.
+	Prefix string
+
+	// Index files that are served for a directory, in order of preference.
+	// Directories without one are not found; their contents are never
+	// listed.
+	Index []string
+
+	// NotFound handles requests for missing files.  It defaults to
+	// http.NotFound.
+	NotFound goji.Handler
+
+	fsys fs.FS
+
+	mu            sync.Mutex
+	hashes        map[string]hashEntry
+	encoded       map[encodedKey]encodedEntry
+	manifest      map[string]string // see LoadManifest
+	manifestFiles map[string]string
+}
+
+// New returns a handler that serves the files in fsys under the given URL
+// prefix.
+func New(fsys fs.FS, prefix string) *Handler {
+	return &Handler{
+		Prefix:  prefix,
+		Index:   []string{"index.html", "index.htm"},
+		fsys:    fsys,
+		hashes:  make(map[string]hashEntry),
+		encoded: make(map[encodedKey]encodedEntry),
+	}
+}
+
+// FS returns the file system that files are served from.
+func (h *Handler) FS() fs.FS {
+	return h.fsys
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.ServeHTTPC(context.TODO(), w, r)
+}
+
+// ServeHTTPC implements goji.Handler.
+func (h *Handler) ServeHTTPC(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, h.Prefix) {
+		h.notFound(ctx, w, r)
+		return
+	}
+
+	upath := strings.TrimPrefix(r.URL.Path, h.Prefix)
+	name := strings.Trim(path.Clean("/"+upath), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := fs.Stat(h.fsys, name)
+	switch {
+	case err == nil && info.IsDir():
+		// Like http.FileServer, redirect to the canonical path of the
+		// directory, so that relative links from its index work.
+		if upath != "" && !strings.HasSuffix(upath, "/") {
+			target := path.Base(upath) + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+		if index, ok := h.index(name); ok {
+			h.serveFile(w, r, index, false)
+			return
+		}
+
+	case err == nil:
+		h.serveFile(w, r, name, false)
+		return
+
+	default:
+		if orig, ok := h.Unfingerprint(name); ok {
+			h.serveFile(w, r, orig, true)
+			return
+		}
+	}
+
+	h.notFound(ctx, w, r)
+}
+
+// index returns the name of the index file in the given directory.
+func (h *Handler) index(dir string) (string, bool) {
+	for _, index := range h.Index {
+		name := path.Join(dir, index)
+		if info, err := fs.Stat(h.fsys, name); err == nil && !info.IsDir() {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (h *Handler) notFound(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if h.NotFound != nil {
+		h.NotFound.ServeHTTPC(ctx, w, r)
+	} else {
+		http.NotFound(w, r)
+	}
+}
+
+// serveFile serves the named file.  Immutable files were requested by their
+// fingerprinted name, so they can be cached forever.
+func (h *Handler) serveFile(w http.ResponseWriter, r *http.Request, name string, immutable bool) {
+	f, err := h.fsys.Open(name)
+	if err != nil {
+		log.Printf("error: could not open static file name=%q err=%q", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		log.Printf("error: could not stat static file name=%q err=%q", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	hash, err := h.Hash(name)
+	if err != nil {
+		log.Printf("error: could not hash static file name=%q err=%q", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			log.Printf("error: could not read static file name=%q err=%q", name, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	// Send a compressed version of the file if the client accepts one.
+	// Each version has its own ETag, since they have different contents.
+	etag := hash
+	w.Header().Add("Vary", "Accept-Encoding")
+	for _, coding := range Encodings {
+		if !middleware.AcceptsEncoding(r, coding) {
+			continue
+		}
+		if encoded, ok := h.Encode(name, coding); ok {
+			w.Header().Set("Content-Encoding", coding)
+			content = bytes.NewReader(encoded)
+			etag += "-" + coding
+			break
+		}
+	}
+
+	if immutable {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("ETag", `"`+etag+`"`)
+
+	log.Printf("debug: serving static file: %s", name)
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
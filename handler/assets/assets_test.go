@@ -0,0 +1,328 @@
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"goji.io"
+	"golang.org/x/net/context"
+)
+
+var (
+	appCSS  = strings.Repeat("body { color: black; }\n", 100)
+	appJS   = strings.Repeat("console.log('hello');\n", 100)
+	appJSBr = "precompressed brotli"
+)
+
+func newTestHandler() (*Handler, *int) {
+	fsys := fstest.MapFS{
+		"index.html":        {Data: []byte("<h1>Home</h1>")},
+		"css/app.css":       {Data: []byte(appCSS)},
+		"js/app.js":         {Data: []byte(appJS)},
+		"js/app.js.br":      {Data: []byte(appJSBr)},
+		"docs/index.html":   {Data: []byte("<h1>Docs</h1>")},
+		"empty/readme.txt":  {Data: []byte("no index here")},
+		"img/logo.png":      {Data: []byte("\x89PNG not really")},
+		"fonts/LICENSE.txt": {Data: []byte("0123456789")},
+	}
+
+	notFound := 0
+	h := New(fsys, "/static/")
+	h.NotFound = goji.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		notFound++
+		http.Error(w, "custom not found", http.StatusNotFound)
+	})
+	return h, &notFound
+}
+
+func get(h http.Handler, path string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", path, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestServeFile(t *testing.T) {
+	h, _ := newTestHandler()
+
+	w := get(h, "/static/css/app.css")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if w.Body.String() != appCSS {
+		t.Errorf("body = %q, want the contents of css/app.css", w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/css") {
+		t.Errorf("Content-Type = %q, want text/css", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Cache-Control = %q, want no-cache", cc)
+	}
+
+	// The ETag can be used to revalidate the file.
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("no ETag")
+	}
+	w = get(h, "/static/css/app.css", "If-None-Match", etag)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("status with If-None-Match = %d, want 304", w.Code)
+	}
+}
+
+func TestDirectoryIndex(t *testing.T) {
+	h, notFound := newTestHandler()
+
+	tests := []struct {
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{path: "/static/", code: http.StatusOK, body: "<h1>Home</h1>"},
+		{path: "/static/docs/", code: http.StatusOK, body: "<h1>Docs</h1>"},
+		{path: "/static/docs", code: http.StatusMovedPermanently, location: "/static/docs/"},
+		{path: "/static/docs?page=2", code: http.StatusMovedPermanently, location: "/static/docs/?page=2"},
+		{path: "/static/empty/", code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		w := get(h, tt.path)
+		if w.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.path, w.Code, tt.code)
+			continue
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("%s: body = %q, want %q", tt.path, w.Body.String(), tt.body)
+		}
+		if loc := w.Header().Get("Location"); loc != tt.location {
+			t.Errorf("%s: Location = %q, want %q", tt.path, loc, tt.location)
+		}
+	}
+
+	if *notFound != 1 {
+		t.Errorf("NotFound called %d times, want once (for a directory without an index)", *notFound)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	h, _ := newTestHandler()
+
+	url, err := h.URL("css/app.css")
+	if err != nil {
+		t.Fatalf("URL: %s", err)
+	}
+	if !strings.HasPrefix(url, "/static/css/app.") || !strings.HasSuffix(url, ".css") ||
+		len(url) != len("/static/css/app.css")+fingerprintLength+1 {
+		t.Fatalf("URL = %q, want /static/css/app.<fingerprint>.css", url)
+	}
+
+	w := get(h, url)
+	if w.Code != http.StatusOK || w.Body.String() != appCSS {
+		t.Fatalf("%s: status = %d, body = %.20q..., want css/app.css", url, w.Code, w.Body.String())
+	}
+	if cc := w.Header().Get("Cache-Control"); !strings.Contains(cc, "immutable") || !strings.Contains(cc, "max-age=31536000") {
+		t.Errorf("Cache-Control = %q, want immutable for a year", cc)
+	}
+
+	// A fingerprint that doesn't match the file's contents isn't found.
+	if w := get(h, "/static/css/app.0123456789.css"); w.Code != http.StatusNotFound {
+		t.Errorf("wrong fingerprint: status = %d, want 404", w.Code)
+	}
+
+	if name, ok := h.Unfingerprint(strings.TrimPrefix(url, "/static/")); !ok || name != "css/app.css" {
+		t.Errorf("Unfingerprint(%q) = %q, %t, want css/app.css", url, name, ok)
+	}
+	if _, err := h.URL("css/missing.css"); err == nil {
+		t.Errorf("URL of a missing file succeeded, want an error")
+	}
+}
+
+func TestRange(t *testing.T) {
+	h, _ := newTestHandler()
+
+	w := get(h, "/static/fonts/LICENSE.txt", "Range", "bytes=2-5")
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("status = %d, want 206", w.Code)
+	}
+	if w.Body.String() != "2345" {
+		t.Errorf("body = %q, want %q", w.Body.String(), "2345")
+	}
+	if cr := w.Header().Get("Content-Range"); cr != "bytes 2-5/10" {
+		t.Errorf("Content-Range = %q, want %q", cr, "bytes 2-5/10")
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	h, _ := newTestHandler()
+
+	tests := []struct {
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		// js/app.js has a precompressed .br file, which is preferred.
+		{"/static/js/app.js", "gzip, br", "br"},
+		{"/static/js/app.js", "gzip", "gzip"},
+		{"/static/js/app.js", "br;q=0, gzip", "gzip"},
+		{"/static/js/app.js", "", ""},
+		// css/app.css is compressed by the handler.
+		{"/static/css/app.css", "br, gzip", "br"},
+		{"/static/css/app.css", "gzip", "gzip"},
+		{"/static/css/app.css", "identity", ""},
+		// Images aren't worth compressing.
+		{"/static/img/logo.png", "br, gzip", ""},
+	}
+
+	etags := make(map[string]string)
+	for _, tt := range tests {
+		w := get(h, tt.path, "Accept-Encoding", tt.acceptEncoding)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s (%q): status = %d, want 200", tt.path, tt.acceptEncoding, w.Code)
+			continue
+		}
+		if enc := w.Header().Get("Content-Encoding"); enc != tt.wantEncoding {
+			t.Errorf("%s (%q): Content-Encoding = %q, want %q", tt.path, tt.acceptEncoding, enc, tt.wantEncoding)
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("%s (%q): Vary = %q, want Accept-Encoding", tt.path, tt.acceptEncoding, vary)
+		}
+
+		// Each encoding of a file has its own ETag.
+		etag := w.Header().Get("ETag")
+		key := tt.path + " " + tt.wantEncoding
+		if prev, ok := etags[key]; ok && prev != etag {
+			t.Errorf("%s: ETag = %s, want %s as before", key, etag, prev)
+		}
+		for k, prev := range etags {
+			if k != key && prev == etag {
+				t.Errorf("%s: ETag %s is the same as for %s", key, etag, k)
+			}
+		}
+		etags[key] = etag
+
+		switch key {
+		case "/static/js/app.js br":
+			if w.Body.String() != appJSBr {
+				t.Errorf("%s: body = %q, want the precompressed file", key, w.Body.String())
+			}
+		case "/static/css/app.css gzip":
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("%s: %s", key, err)
+			}
+			data, err := io.ReadAll(zr)
+			if err != nil || !bytes.Equal(data, []byte(appCSS)) {
+				t.Errorf("%s: body doesn't decompress to css/app.css (err=%v)", key, err)
+			}
+		}
+	}
+}
+
+func TestPrecompress(t *testing.T) {
+	h, _ := newTestHandler()
+	if err := h.Precompress(); err != nil {
+		t.Fatalf("Precompress: %s", err)
+	}
+
+	for _, coding := range Encodings {
+		if _, ok := h.encoded[encodedKey{"css/app.css", coding}]; !ok {
+			t.Errorf("css/app.css was not compressed with %s", coding)
+		}
+		if _, ok := h.encoded[encodedKey{"img/logo.png", coding}]; ok {
+			t.Errorf("img/logo.png was compressed with %s", coding)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	h, notFound := newTestHandler()
+
+	for _, path := range []string{
+		"/static/missing.css",
+		"/static/css/",
+		"/static/../../etc/passwd",
+		"/other/css/app.css",
+	} {
+		w := get(h, path)
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "custom not found") {
+			t.Errorf("%s: status = %d, body = %q, want the NotFound handler", path, w.Code, w.Body.String())
+		}
+	}
+	if *notFound != 4 {
+		t.Errorf("NotFound called %d times, want 4", *notFound)
+	}
+
+	// Without a NotFound handler, http.NotFound is used.
+	h.NotFound = nil
+	if w := get(h, "/static/missing.css"); w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+
+	r := httptest.NewRequest("POST", "/static/css/app.css", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", w.Code)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	h, _ := newTestHandler()
+	fsys := h.FS().(fstest.MapFS)
+
+	manifest, err := BuildManifest(fsys, regexp.MustCompile(`\.br$`))
+	if err != nil {
+		t.Fatalf("BuildManifest: %s", err)
+	}
+	if _, ok := manifest["js/app.js.br"]; ok {
+		t.Errorf("BuildManifest included an ignored file")
+	}
+	want, _ := h.Fingerprint("css/app.css")
+	if manifest["css/app.css"] != want {
+		t.Errorf("manifest[css/app.css] = %q, want %q", manifest["css/app.css"], want)
+	}
+
+	// Once a manifest is loaded, fingerprinted names are looked up in it,
+	// even if they don't match the file's contents.
+	fsys[ManifestName] = &fstest.MapFile{Data: []byte(`{"css/app.css": "css/app.0123456789.css"}`)}
+	if err := h.LoadManifest(); err != nil {
+		t.Fatalf("LoadManifest: %s", err)
+	}
+
+	url, err := h.URL("css/app.css")
+	if err != nil || url != "/static/css/app.0123456789.css" {
+		t.Errorf("URL = %q, %v, want /static/css/app.0123456789.css", url, err)
+	}
+	w := get(h, url)
+	if w.Code != http.StatusOK || w.Body.String() != appCSS {
+		t.Errorf("%s: status = %d, want css/app.css", url, w.Code)
+	}
+	if cc := w.Header().Get("Cache-Control"); !strings.Contains(cc, "immutable") {
+		t.Errorf("%s: Cache-Control = %q, want immutable", url, cc)
+	}
+
+	// The fingerprint from the contents isn't used, and files missing from
+	// the manifest can't be linked to.
+	if w := get(h, "/static/"+want); w.Code != http.StatusNotFound {
+		t.Errorf("/static/%s: status = %d, want 404", want, w.Code)
+	}
+	if _, err := h.URL("js/app.js"); err == nil {
+		t.Errorf("URL of a file missing from the manifest succeeded, want an error")
+	}
+
+	fsys[ManifestName] = &fstest.MapFile{Data: []byte(`["css/app.css"]`)}
+	if err := h.LoadManifest(); err == nil {
+		t.Errorf("LoadManifest of an invalid manifest succeeded, want an error")
+	}
+}
@@ -0,0 +1,132 @@
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Encodings are the content codings that files can be served with, in order
+// of preference.
+//
+// Compressible files are compressed with each of them once, by Precompress
+// or when they are first served, and the results are kept in memory.  A file
+// that was compressed ahead of time, such as "js/app.js.br" for "js/app.js"
+// (or ".gz" for gzip), is used instead, if there is one.
+var Encodings = []string{"br", "gzip"}
+
+// File extensions of precompressed files, by content coding.
+var encodingExts = map[string]string{
+	"br":   ".br",
+	"gzip": ".gz",
+}
+
+type encodedKey struct {
+	name   string
+	coding string
+}
+
+// encodedEntry is the compressed contents of a file, cached by Encode.
+type encodedEntry struct {
+	hash string
+	data []byte // nil if compressing didn't make the file smaller
+}
+
+// Encode returns the contents of the file in the given content coding (one
+// of Encodings), or false if it isn't available in that coding.
+func (h *Handler) Encode(name, coding string) ([]byte, bool) {
+	ext, ok := encodingExts[coding]
+	if !ok {
+		return nil, false
+	}
+	if data, err := fs.ReadFile(h.fsys, name+ext); err == nil {
+		return data, true
+	}
+	if !compressible(name) {
+		return nil, false
+	}
+
+	hash, err := h.Hash(name)
+	if err != nil {
+		return nil, false
+	}
+
+	key := encodedKey{name, coding}
+	h.mu.Lock()
+	entry, ok := h.encoded[key]
+	h.mu.Unlock()
+	if !ok || entry.hash != hash {
+		entry = encodedEntry{hash: hash, data: h.compressFile(name, coding)}
+
+		h.mu.Lock()
+		h.encoded[key] = entry
+		h.mu.Unlock()
+	}
+	return entry.data, entry.data != nil
+}
+
+// Precompress compresses every compressible file with each of the
+// Encodings, so that the first requests for them don't have to wait.
+func (h *Handler) Precompress() error {
+	return fs.WalkDir(h.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		for _, coding := range Encodings {
+			h.Encode(name, coding)
+		}
+		return nil
+	})
+}
+
+// compressFile returns the contents of the file compressed with the given
+// coding, or nil if that isn't any smaller than the file itself.
+func (h *Handler) compressFile(name, coding string) []byte {
+	data, err := fs.ReadFile(h.fsys, name)
+	if err != nil {
+		return nil
+	}
+
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	switch coding {
+	case "br":
+		w = brotli.NewWriterLevel(&buf, brotli.BestCompression)
+	case "gzip":
+		w, _ = gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	default:
+		return nil
+	}
+	w.Write(data)
+	w.Close()
+
+	if buf.Len() >= len(data) {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// compressible returns whether the file is of a type that's worth
+// compressing, judging by its extension.  Most images, video and archives
+// are already compressed.
+func compressible(name string) bool {
+	typ := mime.TypeByExtension(path.Ext(name))
+	if i := strings.Index(typ, ";"); i >= 0 {
+		typ = typ[:i]
+	}
+
+	switch typ {
+	case "application/javascript", "application/json", "application/xml",
+		"application/wasm", "image/svg+xml", "image/x-icon", "image/vnd.microsoft.icon":
+		return true
+	}
+	return strings.HasPrefix(typ, "text/")
+}
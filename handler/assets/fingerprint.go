@@ -0,0 +1,137 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// Length of the content hash in fingerprinted file names, in hex digits.
+const fingerprintLength = 10
+
+// hashEntry is the cached hash of a file.  It is recomputed if the file's
+// size or modification time changes, which only happens when files are read
+// from disk.
+type hashEntry struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+// Hash returns the SHA-256 hash of the file's contents, in hex.
+func (h *Handler) Hash(name string) (string, error) {
+	info, err := fs.Stat(h.fsys, name)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", name)
+	}
+
+	h.mu.Lock()
+	entry, ok := h.hashes[name]
+	h.mu.Unlock()
+	if ok && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+		return entry.hash, nil
+	}
+
+	data, err := fs.ReadFile(h.fsys, name)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	entry = hashEntry{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		hash:    hex.EncodeToString(sum[:]),
+	}
+
+	h.mu.Lock()
+	h.hashes[name] = entry
+	h.mu.Unlock()
+	return entry.hash, nil
+}
+
+// Fingerprint returns the name of the file with a hash of its contents
+// inserted before the extension, e.g. "css/app.3f9a1c2b7d.css" for
+// "css/app.css".  The fingerprinted name changes whenever the file does, so
+// responses for it can be cached forever.
+//
+// If a manifest was loaded, the fingerprinted name is looked up in it
+// instead.
+func (h *Handler) Fingerprint(name string) (string, error) {
+	if manifest, _ := h.lookupManifest(); manifest != nil {
+		fp, ok := manifest[name]
+		if !ok {
+			return "", &fs.PathError{Op: "fingerprint", Path: name, Err: fs.ErrNotExist}
+		}
+		return fp, nil
+	}
+
+	hash, err := h.Hash(name)
+	if err != nil {
+		return "", err
+	}
+
+	dir, file := path.Split(name)
+	ext := path.Ext(file)
+	if ext == file {
+		// Dotfiles, like ".htaccess", have no extension.
+		ext = ""
+	}
+	return dir + strings.TrimSuffix(file, ext) + "." + hash[:fingerprintLength] + ext, nil
+}
+
+// Unfingerprint returns the name of the file with the given fingerprinted
+// name (see Fingerprint).  It returns false if there is no such file, or the
+// fingerprint doesn't match the file's current contents (or its entry in the
+// manifest).
+func (h *Handler) Unfingerprint(fingerprinted string) (string, bool) {
+	if _, files := h.lookupManifest(); files != nil {
+		name, ok := files[fingerprinted]
+		return name, ok
+	}
+
+	dir, file := path.Split(fingerprinted)
+	parts := strings.Split(file, ".")
+
+	// The hash is either just before the extension, or at the end if the
+	// file has no extension.
+	for _, i := range []int{len(parts) - 2, len(parts) - 1} {
+		if i < 1 || !isFingerprint(parts[i]) {
+			continue
+		}
+
+		rest := append(append([]string(nil), parts[:i]...), parts[i+1:]...)
+		name := dir + strings.Join(rest, ".")
+		if fp, err := h.Fingerprint(name); err == nil && fp == fingerprinted {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func isFingerprint(s string) bool {
+	if len(s) != fingerprintLength {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// URL returns the fingerprinted URL of the named file.
+func (h *Handler) URL(name string) (string, error) {
+	fp, err := h.Fingerprint(strings.TrimPrefix(name, "/"))
+	if err != nil {
+		return "", err
+	}
+	return h.Prefix + fp, nil
+}
@@ -0,0 +1,51 @@
+// Command genmanifest writes the manifest of the static files in a directory
+// (see assets.ManifestName), which lists the fingerprinted name of each file.
+// The Makefile runs it before embedding the files; for example,
+//
+//     go run ./handler/assets/genmanifest -ignore '\.go$' static
+//
+// writes static/manifest.json, leaving out any Go files.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/andrew-d/go-webapp-skeleton/handler/assets"
+)
+
+func main() {
+	ignore := flag.String("ignore", "", "regular expression for files to leave out, as for go-bindata")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s [-ignore regexp] dir\n", os.Args[0])
+		os.Exit(2)
+	}
+	dir := flag.Arg(0)
+
+	var re *regexp.Regexp
+	if *ignore != "" {
+		var err error
+		if re, err = regexp.Compile(*ignore); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: invalid -ignore: %s\n", os.Args[0], err)
+			os.Exit(2)
+		}
+	}
+
+	manifest, err := assets.BuildManifest(os.DirFS(dir), re)
+	if err == nil {
+		var data []byte
+		data, err = json.MarshalIndent(manifest, "", "\t")
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, assets.ManifestName), append(data, '\n'), 0644)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+		os.Exit(1)
+	}
+}
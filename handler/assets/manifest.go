@@ -0,0 +1,78 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+)
+
+// ManifestName is the name of the manifest file, in the root of the file
+// system that it lists.
+//
+// The manifest maps the name of every file to its fingerprinted name (see
+// Fingerprint).  It is written by the genmanifest command when the files are
+// built (see the Makefile), and read by LoadManifest when they are served, so
+// that links to files are looked up instead of hashing every file.
+const ManifestName = "manifest.json"
+
+// BuildManifest returns the fingerprinted name of every file in fsys, keyed
+// by the file's name.  Files whose names match ignore, if it isn't nil, and
+// the manifest itself, are left out.
+func BuildManifest(fsys fs.FS, ignore *regexp.Regexp) (map[string]string, error) {
+	h := New(fsys, "/")
+	m := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if name == ManifestName || (ignore != nil && ignore.MatchString(name)) {
+			return nil
+		}
+
+		fp, err := h.Fingerprint(name)
+		if err != nil {
+			return err
+		}
+		m[name] = fp
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LoadManifest reads the manifest from the handler's file system.  From then
+// on, fingerprinted names are looked up in it, rather than computed from the
+// files' contents, and files that aren't in it can only be requested by
+// their plain names.
+func (h *Handler) LoadManifest() error {
+	data, err := fs.ReadFile(h.fsys, ManifestName)
+	if err != nil {
+		return err
+	}
+
+	var manifest map[string]string
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("invalid %s: %s", ManifestName, err)
+	}
+	files := make(map[string]string, len(manifest))
+	for name, fp := range manifest {
+		files[fp] = name
+	}
+
+	h.mu.Lock()
+	h.manifest = manifest
+	h.manifestFiles = files
+	h.mu.Unlock()
+	return nil
+}
+
+// lookupManifest returns the manifest and its inverse (file names keyed by
+// fingerprinted names), or nil if no manifest was loaded.
+func (h *Handler) lookupManifest() (manifest, files map[string]string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.manifest, h.manifestFiles
+}
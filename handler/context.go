@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
 )
 
 const (
@@ -20,7 +24,7 @@ func ToLimit(r *http.Request) int {
 	}
 
 	limit, err := strconv.Atoi(r.FormValue("limit"))
-	if err != nil {
+	if err != nil || limit < 1 {
 		return DEFAULT_LIMIT
 	}
 
@@ -31,18 +35,65 @@ func ToLimit(r *http.Request) int {
 	return limit
 }
 
-// ToOffset returns the Offset from current request
-// query if offset doesn't present set default offset
-// equal to 0
-func ToOffset(r *http.Request) int {
-	if len(r.FormValue("offset")) == 0 {
-		return 0
+// ToCursor returns the pagination cursor from the current request, or nil if
+// no cursor was given.
+func ToCursor(r *http.Request) (*datastore.Cursor, error) {
+	return datastore.DecodeCursor(r.FormValue("cursor"))
+}
+
+// ToSort returns the fields to sort by from the current request.  Fields are
+// given as a comma-separated list, where a '-' prefix sorts in descending
+// order (e.g. "?sort=name,-id").
+func ToSort(r *http.Request) []datastore.SortField {
+	return datastore.ParseSort(r.FormValue("sort"))
+}
+
+// ToIncludeTotal returns whether the current request asked for the total
+// number of matching records (e.g. "?total=true"), which costs an extra
+// query.
+func ToIncludeTotal(r *http.Request) bool {
+	total, err := strconv.ParseBool(r.FormValue("total"))
+	return err == nil && total
+}
+
+// ToIDs returns the list of IDs from the current request, given as a
+// comma-separated list (e.g. "?id=1,2,3").  At most MAXIMUM_LIMIT IDs may be
+// given.
+func ToIDs(r *http.Request) ([]int64, error) {
+	if len(r.FormValue("id")) == 0 {
+		return nil, nil
+	}
+
+	parts := strings.Split(r.FormValue("id"), ",")
+	if len(parts) > MAXIMUM_LIMIT {
+		return nil, fmt.Errorf("too many IDs given (maximum is %d)", MAXIMUM_LIMIT)
 	}
 
-	offset, err := strconv.Atoi(r.FormValue("offset"))
+	ids := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID: %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ToPeopleFilter returns the filter for listing people from the current
+// request.  The "q" parameter searches for people whose name contains the
+// given string; a trailing '*' searches by prefix instead (e.g. "?q=jo*").
+func ToPeopleFilter(r *http.Request) (*datastore.PeopleFilter, error) {
+	ids, err := ToIDs(r)
 	if err != nil {
-		return 0
+		return nil, err
 	}
 
-	return offset
+	filter := &datastore.PeopleFilter{IDs: ids}
+	if q := r.FormValue("q"); strings.HasSuffix(q, "*") {
+		filter.Name, filter.NamePrefix = strings.TrimSuffix(q, "*"), true
+	} else {
+		filter.Name = q
+	}
+	return filter, nil
 }
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestToIncludeTotal(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"/api/people", false},
+		{"/api/people?total=true", true},
+		{"/api/people?total=1", true},
+		{"/api/people?total=false", false},
+		{"/api/people?total=", false},
+		{"/api/people?total=yes", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.url, nil)
+		if got := ToIncludeTotal(r); got != tt.want {
+			t.Errorf("ToIncludeTotal(%q) = %t, want %t", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+)
+
+// ErrBodyTooLarge is returned by DecodeJSON and ParseForm if the request body
+// is larger than the configured maximum size.
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// ErrMultipleJSONValues is returned by DecodeJSON if the request body has
+// anything other than whitespace after the JSON value.
+var ErrMultipleJSONValues = errors.New("request body must contain a single JSON value")
+
+// DecodeJSON decodes the JSON request body into v.  Fields in the body that
+// don't exist in v are rejected, as are bodies that contain more than one
+// JSON value, and bodies larger than the configured maximum size (see
+// conf.Config.MaxBodySize).
+func DecodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	defer r.Body.Close()
+
+	body := http.MaxBytesReader(w, r.Body, conf.C.MaxBodySize)
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		if _, ok := err.(*http.MaxBytesError); ok {
+			return ErrBodyTooLarge
+		}
+		return err
+	}
+
+	// Decode stops at the end of the first value, so check that there's
+	// nothing after it.
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		if _, ok := err.(*http.MaxBytesError); ok {
+			return ErrBodyTooLarge
+		}
+		return ErrMultipleJSONValues
+	}
+	return nil
+}
+
+// ParseForm parses the form in the request body (see http.Request.ParseForm),
+// rejecting bodies larger than the configured maximum size.
+func ParseForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, conf.C.MaxBodySize)
+
+	if err := r.ParseForm(); err != nil {
+		if _, ok := err.(*http.MaxBytesError); ok {
+			return ErrBodyTooLarge
+		}
+		return err
+	}
+	return nil
+}
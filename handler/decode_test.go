@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	conf.C = conf.Default()
+	conf.C.MaxBodySize = 32
+	defer func() { conf.C = nil }()
+
+	tests := []struct {
+		body     string
+		wantName string
+		wantErr  error
+	}{
+		{`{"name": "Alice"}`, "Alice", nil},
+		{"  {\"name\": \"Alice\"}\n\n", "Alice", nil},
+		{`{"name": "Alice"}{"name": "Bob"}`, "Alice", ErrMultipleJSONValues},
+		{`{"name": "Alice"} {}`, "Alice", ErrMultipleJSONValues},
+		{`{"name": "Alice"} garbage`, "Alice", ErrMultipleJSONValues},
+		{`{"name": "Alice"}` + strings.Repeat(" ", 32), "Alice", ErrBodyTooLarge},
+		{`{"name": "` + strings.Repeat("A", 32) + `"}`, "", ErrBodyTooLarge},
+	}
+
+	for _, tt := range tests {
+		var v struct {
+			Name string `json:"name"`
+		}
+		r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+		err := DecodeJSON(httptest.NewRecorder(), r, &v)
+		if err != tt.wantErr {
+			t.Errorf("DecodeJSON(%q) returned %v, want %v", tt.body, err, tt.wantErr)
+		}
+		if v.Name != tt.wantName {
+			t.Errorf("DecodeJSON(%q) decoded name %q, want %q", tt.body, v.Name, tt.wantName)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+	"github.com/andrew-d/go-webapp-skeleton/validate"
+)
+
+// ErrorStatus returns the HTTP status code that should be sent in response
+// to the given error from the datastore.
+//
+// Validation errors from the validate package are also handled.  Errors that
+// aren't one of the datastore package's sentinel errors are treated as
+// internal server errors.
+func ErrorStatus(err error) int {
+	if _, ok := err.(validate.Errors); ok {
+		return http.StatusUnprocessableEntity
+	}
+
+	switch err {
+	case nil:
+		return http.StatusOK
+	case datastore.ErrInvalidCursor, datastore.ErrInvalidSort:
+		return http.StatusBadRequest
+	case datastore.ErrNotFound:
+		return http.StatusNotFound
+	case datastore.ErrConflict:
+		return http.StatusConflict
+	case datastore.ErrValidation:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,143 @@
+package frontend
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/handler"
+	"github.com/andrew-d/go-webapp-skeleton/session"
+	"github.com/andrew-d/go-webapp-skeleton/validate"
+)
+
+// homePath is where users are sent after registering, logging in or logging
+// out.
+const homePath = "/people"
+
+// ShowRegister shows the registration form.
+//
+//     GET /register
+//
+func ShowRegister(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if err := renderTemplate(ctx, w, "register.tmpl", M{}); err != nil {
+		renderTemplateError(ctx, w, err)
+	}
+}
+
+// Register accepts a request to create a new account, and logs the new user
+// in.
+//
+//     POST /register
+//
+func Register(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if !parseForm(ctx, w, r) {
+		return
+	}
+
+	email := r.PostFormValue("email")
+	user, err := auth.Register(ctx, email, r.PostFormValue("password"))
+	if verrs, ok := err.(validate.Errors); ok {
+		if err := renderTemplate(ctx, w, "register.tmpl", M{
+			"Email":  email,
+			"Errors": verrs,
+		}); err != nil {
+			renderTemplateError(ctx, w, err)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("error: error registering user err=%q", err)
+		renderError(ctx, w, handler.ErrorStatus(err))
+		return
+	}
+
+	auth.Login(ctx, user)
+	session.FromContext(ctx).AddFlash(session.FlashSuccess, "Your account has been created.")
+	http.Redirect(w, r, homePath, http.StatusSeeOther)
+}
+
+// ShowLogin shows the login form.
+//
+//     GET /login?next=:path
+//
+func ShowLogin(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if err := renderTemplate(ctx, w, "login.tmpl", M{
+		"Next": localPath(r.URL.Query().Get("next")),
+	}); err != nil {
+		renderTemplateError(ctx, w, err)
+	}
+}
+
+// Login accepts a request to log in, and redirects to the page given in the
+// 'next' field.
+//
+//     POST /login
+//
+func Login(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if !parseForm(ctx, w, r) {
+		return
+	}
+
+	var (
+		email = r.PostFormValue("email")
+		next  = localPath(r.PostFormValue("next"))
+	)
+
+	user, err := auth.Authenticate(ctx, email, r.PostFormValue("password"))
+	if err == auth.ErrInvalidCredentials {
+		if err := renderTemplate(ctx, w, "login.tmpl", M{
+			"Email": email,
+			"Next":  next,
+			"Error": "Invalid email address or password.",
+		}); err != nil {
+			renderTemplateError(ctx, w, err)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("error: error authenticating user err=%q", err)
+		renderError(ctx, w, handler.ErrorStatus(err))
+		return
+	}
+
+	auth.Login(ctx, user)
+	if next == "" {
+		next = homePath
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// Logout accepts a request to log out.
+//
+//     POST /logout
+//
+func Logout(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	auth.Logout(ctx)
+	http.Redirect(w, r, homePath, http.StatusSeeOther)
+}
+
+// localPath returns the given path if it refers to a page on this site, and
+// the empty string otherwise, so that it can't be used to redirect to another
+// site.
+func localPath(path string) string {
+	// Browsers strip tabs and newlines from URLs, so "/\t/example.com" is
+	// "//example.com" to them, and treat backslashes as slashes.
+	for i := 0; i < len(path); i++ {
+		if path[i] < 0x20 || path[i] == 0x7f {
+			return ""
+		}
+	}
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return ""
+	}
+
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,30 @@
+package frontend
+
+import "testing"
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/people", "/people"},
+		{"/people/1/edit?tab=name#top", "/people/1/edit?tab=name#top"},
+		{"/%09/example.com", "/%09/example.com"},
+		{"", ""},
+		{"people", ""},
+		{"http://example.com/", ""},
+		{"//example.com", ""},
+		{"/\\example.com", ""},
+		{"/\t/example.com", ""},
+		{"/\n/example.com", ""},
+		{"/\r\n/example.com", ""},
+		{"/\x7f/example.com", ""},
+		{"/%zz", ""},
+	}
+
+	for _, tt := range tests {
+		if got := localPath(tt.path); got != tt.want {
+			t.Errorf("localPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package frontend
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+)
+
+// Messages shown on the error pages for each status.
+var errorMessages = map[int]string{
+	http.StatusBadRequest:            "Your request couldn't be understood.",
+	http.StatusForbidden:             "You don't have permission to view this page.",
+	http.StatusNotFound:              "The page you were looking for doesn't exist.",
+	http.StatusRequestEntityTooLarge: "The form you sent was too large.",
+	http.StatusInternalServerError:   "Something went wrong on our end.  Please try again later.",
+}
+
+// NotFound is the fallback handler for frontend routes that don't exist.
+func NotFound(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	renderError(ctx, w, http.StatusNotFound)
+}
+
+// renderError sends the error page for the given status, with the default
+// message for that status.
+func renderError(ctx context.Context, w http.ResponseWriter, status int) {
+	ErrorPage(ctx, w, status, "")
+}
+
+// ErrorPage sends the error page for the given status.  If message is empty,
+// a default message for the status is shown.  If the error page itself can't
+// be rendered, a plain-text error is sent instead.
+func ErrorPage(ctx context.Context, w http.ResponseWriter, status int, message string) {
+	if message == "" {
+		message = errorMessages[status]
+	}
+	if message == "" && status >= 500 {
+		message = errorMessages[http.StatusInternalServerError]
+	}
+
+	err := renderTemplateStatus(ctx, w, status, "error.tmpl", M{
+		"Status":  status,
+		"Title":   http.StatusText(status),
+		"Message": message,
+	})
+	if err != nil {
+		log.Printf("error: could not render error page status=%d err=%q", status, err)
+
+		// Most likely the templates themselves are broken, so show
+		// developers what went wrong.
+		if conf.C.IsDebug() {
+			debugErrorPage(w, status, err)
+			return
+		}
+
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+// renderTemplateError sends an error page for a template that couldn't be
+// rendered.  In debug mode, the error itself is shown, so that mistakes in
+// templates are easy to find.
+func renderTemplateError(ctx context.Context, w http.ResponseWriter, err error) {
+	if conf.C.IsDebug() {
+		debugErrorPage(w, http.StatusInternalServerError, err)
+		return
+	}
+	renderError(ctx, w, http.StatusInternalServerError)
+}
+
+// debugErrorPage sends a page showing the given error.  It doesn't use any of
+// the regular templates, since they may be what's broken.
+func debugErrorPage(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	debugErrorTemplate.Execute(w, M{
+		"Status": status,
+		"Title":  http.StatusText(status),
+		"Error":  err.Error(),
+	})
+}
+
+var debugErrorTemplate = template.Must(template.New("debug_error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <title>{{.Status}} {{.Title}}</title>
+</head>
+<body>
+    <h2>{{.Status}} {{.Title}}</h2>
+    <p>The page could not be rendered:</p>
+    <pre>{{.Error}}</pre>
+    <p><small>This page is only shown in debug mode.</small></p>
+</body>
+</html>
+`))
@@ -0,0 +1,141 @@
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/handler"
+	"github.com/andrew-d/go-webapp-skeleton/handler/assets"
+	"github.com/andrew-d/go-webapp-skeleton/middleware"
+)
+
+// templateFuncs are the functions available to every template:
+//
+//     urlFor NAME PARAMS...     the path of a named route (see handler.URL),
+//                               e.g. {{ urlFor "person_edit" .Person.ID }}
+//     asset NAME                the fingerprinted URL of a static file,
+//                               e.g. {{ asset "css/app.css" }}
+//     formatTime LAYOUT T       a time.Time or Unix timestamp, formatted
+//                               with the given layout
+//     date T                    like formatTime, as "Jan 2, 2006"
+//     datetime T                like formatTime, as "Jan 2, 2006 15:04 MST"
+//     pluralize N ONE MANY      N followed by ONE or MANY, e.g. "3 people"
+//     truncate N S              S cut to at most N characters, with an
+//                               ellipsis if it was longer
+//     json V                    V encoded as JSON, safe to use in a <script>
+//
+// Functions that depend on the current request are only placeholders here;
+// see requestFuncs.
+var templateFuncs = template.FuncMap{
+	"urlFor":     handler.URL,
+	"asset":      assetURL,
+	"formatTime": formatTime,
+	"date":       func(t interface{}) (string, error) { return formatTime("Jan 2, 2006", t) },
+	"datetime":   func(t interface{}) (string, error) { return formatTime("Jan 2, 2006 15:04 MST", t) },
+	"pluralize":  pluralize,
+	"truncate":   truncate,
+	"json":       toJSON,
+
+	"csrfField": func() template.HTML { return "" },
+	"csrfToken": func() string { return "" },
+}
+
+// requestFuncs returns the template functions that depend on the current
+// request:
+//
+//     csrfField    a hidden form field holding a CSRF token, for use in
+//                  every form that POSTs to the site
+//     csrfToken    the CSRF token itself
+//
+func requestFuncs(ctx context.Context) template.FuncMap {
+	return template.FuncMap{
+		"csrfField": func() template.HTML {
+			return template.HTML(`<input type="hidden" name="` + middleware.CSRFField +
+				`" value="` + template.HTMLEscapeString(middleware.CSRFToken(ctx)) + `">`)
+		},
+		"csrfToken": func() string {
+			return middleware.CSRFToken(ctx)
+		},
+	}
+}
+
+// Assets serves the static files that templates link to with the asset
+// function.
+var Assets *assets.Handler
+
+// assetURL returns the URL of the static file with the given name.  The URL
+// includes a fingerprint of the file's contents (see
+// assets.Handler.Fingerprint), so browsers can cache it forever.
+func assetURL(name string) (string, error) {
+	if Assets == nil {
+		return "", fmt.Errorf("no static files to link to")
+	}
+	url, err := Assets.URL(name)
+	if err != nil {
+		return "", fmt.Errorf("no static file named %q", name)
+	}
+	return url, nil
+}
+
+func formatTime(layout string, t interface{}) (string, error) {
+	switch t := t.(type) {
+	case time.Time:
+		return t.Format(layout), nil
+	case *time.Time:
+		if t == nil {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	case int64:
+		return time.Unix(t, 0).UTC().Format(layout), nil
+	case int:
+		return time.Unix(int64(t), 0).UTC().Format(layout), nil
+	default:
+		return "", fmt.Errorf("can't format %T as a time", t)
+	}
+}
+
+func pluralize(n interface{}, singular, plural string) (string, error) {
+	var count int64
+	switch n := n.(type) {
+	case int:
+		count = int64(n)
+	case int64:
+		count = n
+	default:
+		return "", fmt.Errorf("can't pluralize with a count of type %T", n)
+	}
+
+	if count == 1 {
+		return "1 " + singular, nil
+	}
+	return fmt.Sprintf("%d %s", count, plural), nil
+}
+
+func truncate(n int, s string) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n < 1 {
+		return ""
+	}
+
+	runes := []rune(s)
+	return strings.TrimRight(string(runes[:n-1]), " ") + "…"
+}
+
+func toJSON(v interface{}) (template.JS, error) {
+	// json.Marshal escapes '<', '>' and '&', so the result can't close a
+	// <script> element.
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(buf), nil
+}
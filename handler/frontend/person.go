@@ -10,6 +10,9 @@ import (
 
 	"github.com/andrew-d/go-webapp-skeleton/datastore"
 	"github.com/andrew-d/go-webapp-skeleton/handler"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+	"github.com/andrew-d/go-webapp-skeleton/session"
+	"github.com/andrew-d/go-webapp-skeleton/validate"
 )
 
 // ListPeople shows a list of all people
@@ -17,21 +20,42 @@ import (
 //     GET /people
 //
 func ListPeople(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	var (
-		limit  = handler.ToLimit(r)
-		offset = handler.ToOffset(r)
-	)
+	cursor, err := handler.ToCursor(r)
+	if err != nil {
+		renderError(ctx, w, http.StatusBadRequest)
+		return
+	}
+
+	filter, err := handler.ToPeopleFilter(r)
+	if err != nil {
+		renderError(ctx, w, http.StatusBadRequest)
+		return
+	}
 
-	people, err := datastore.ListPeople(ctx, limit, offset)
+	people, page, err := datastore.ListPeople(ctx, filter, &datastore.ListOptions{
+		Limit:  handler.ToLimit(r),
+		Cursor: cursor,
+		Sort:   handler.ToSort(r),
+	})
 	if err != nil {
 		log.Printf("error: error listing people err=%q", err)
-		w.WriteHeader(http.StatusNotFound)
+		renderError(ctx, w, handler.ErrorStatus(err))
 		return
 	}
 
-	renderTemplate(ctx, w, "person_list.tmpl", M{
+	m := M{
 		"People": people,
-	})
+	}
+	if page.Prev != nil {
+		m["PrevURL"] = handler.PageURL(r, page.Prev)
+	}
+	if page.Next != nil {
+		m["NextURL"] = handler.PageURL(r, page.Next)
+	}
+
+	if err := renderTemplate(ctx, w, "person_list.tmpl", m); err != nil {
+		renderTemplateError(ctx, w, err)
+	}
 }
 
 // GetPerson accepts a request to retrieve information about a particular person.
@@ -45,18 +69,182 @@ func GetPerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		renderError(ctx, w, http.StatusBadRequest)
 		return
 	}
 
 	person, err := datastore.GetPerson(ctx, id)
 	if err != nil {
 		log.Printf("error: error getting person err=%q", err)
-		w.WriteHeader(http.StatusNotFound)
+		renderError(ctx, w, handler.ErrorStatus(err))
 		return
 	}
 
-	renderTemplate(ctx, w, "person_show.tmpl", M{
+	if err := renderTemplate(ctx, w, "person_show.tmpl", M{
 		"Person": person,
-	})
+	}); err != nil {
+		renderTemplateError(ctx, w, err)
+	}
+}
+
+// NewPerson shows the form to create a new person.
+//
+//     GET /people/new
+//
+func NewPerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if err := renderTemplate(ctx, w, "person_new.tmpl", M{
+		"Person": &model.Person{},
+	}); err != nil {
+		renderTemplateError(ctx, w, err)
+	}
+}
+
+// CreatePerson accepts a request to create a new person from the form.  If
+// the person is invalid, the form is shown again with the errors, with a 422
+// Unprocessable Entity status.
+//
+//     POST /people
+//
+func CreatePerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if !parseForm(ctx, w, r) {
+		return
+	}
+
+	person := &model.Person{Name: r.PostFormValue("name")}
+	if err := validate.Struct(person); err != nil {
+		if err := renderTemplateStatus(ctx, w, http.StatusUnprocessableEntity, "person_new.tmpl", M{
+			"Person": person,
+			"Errors": err,
+		}); err != nil {
+			renderTemplateError(ctx, w, err)
+		}
+		return
+	}
+
+	err := datastore.CreatePerson(ctx, person)
+	if err != nil {
+		log.Printf("error: error saving person err=%q", err)
+		renderError(ctx, w, handler.ErrorStatus(err))
+		return
+	}
+
+	session.FromContext(ctx).AddFlash(session.FlashSuccess, "Created "+person.Name+".")
+	http.Redirect(w, r, personPath(person), http.StatusSeeOther)
+}
+
+// EditPerson shows the form to change a person.
+//
+//     GET /people/:person/edit
+//
+func EditPerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	person := loadPerson(ctx, w)
+	if person == nil {
+		return
+	}
+
+	if err := renderTemplate(ctx, w, "person_edit.tmpl", M{
+		"Person": person,
+	}); err != nil {
+		renderTemplateError(ctx, w, err)
+	}
+}
+
+// UpdatePerson accepts a request to change a person from the form.  If the
+// changes are invalid, the form is shown again with the errors, with a 422
+// Unprocessable Entity status.
+//
+//     POST /people/:person/edit
+//
+func UpdatePerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	person := loadPerson(ctx, w)
+	if person == nil {
+		return
+	}
+
+	if !parseForm(ctx, w, r) {
+		return
+	}
+
+	person.Name = r.PostFormValue("name")
+	if err := validate.Struct(person); err != nil {
+		if err := renderTemplateStatus(ctx, w, http.StatusUnprocessableEntity, "person_edit.tmpl", M{
+			"Person": person,
+			"Errors": err,
+		}); err != nil {
+			renderTemplateError(ctx, w, err)
+		}
+		return
+	}
+
+	err := datastore.UpdatePerson(ctx, person)
+	if err != nil {
+		log.Printf("error: error updating person err=%q", err)
+		renderError(ctx, w, handler.ErrorStatus(err))
+		return
+	}
+
+	session.FromContext(ctx).AddFlash(session.FlashSuccess, "Saved "+person.Name+".")
+	http.Redirect(w, r, personPath(person), http.StatusSeeOther)
+}
+
+// ConfirmDeletePerson asks the user to confirm that a person should be
+// deleted.
+//
+//     GET /people/:person/delete
+//
+func ConfirmDeletePerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	person := loadPerson(ctx, w)
+	if person == nil {
+		return
+	}
+
+	if err := renderTemplate(ctx, w, "person_delete.tmpl", M{
+		"Person": person,
+	}); err != nil {
+		renderTemplateError(ctx, w, err)
+	}
+}
+
+// DeletePerson accepts a request to delete a person.
+//
+//     POST /people/:person/delete
+//
+func DeletePerson(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	person := loadPerson(ctx, w)
+	if person == nil {
+		return
+	}
+
+	err := datastore.DeletePerson(ctx, person.ID)
+	if err != nil {
+		log.Printf("error: error deleting person err=%q", err)
+		renderError(ctx, w, handler.ErrorStatus(err))
+		return
+	}
+
+	session.FromContext(ctx).AddFlash(session.FlashSuccess, "Deleted "+person.Name+".")
+	http.Redirect(w, r, "/people", http.StatusSeeOther)
+}
+
+// loadPerson retrieves the person given in the URL.  If there is no such
+// person, an error is sent to the client and nil is returned.
+func loadPerson(ctx context.Context, w http.ResponseWriter) *model.Person {
+	id, err := strconv.ParseInt(pat.Param(ctx, "person"), 10, 64)
+	if err != nil {
+		renderError(ctx, w, http.StatusBadRequest)
+		return nil
+	}
+
+	person, err := datastore.GetPerson(ctx, id)
+	if err != nil {
+		log.Printf("error: error getting person err=%q", err)
+		renderError(ctx, w, handler.ErrorStatus(err))
+		return nil
+	}
+	return person
+}
+
+// personPath returns the path of the page that shows the given person.
+func personPath(person *model.Person) string {
+	return "/people/" + strconv.FormatInt(person.ID, 10)
 }
@@ -0,0 +1,105 @@
+package frontend
+
+import (
+	"bytes"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+	"github.com/andrew-d/go-webapp-skeleton/middleware"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+// ID of the element that holds the bootstrap data in the SPA's page.
+const spaBootstrapID = "bootstrap"
+
+// spaBootstrap is the data embedded in the SPA's page, if
+// conf.Config.SPABootstrap is set.  The app can read it with:
+//
+//     JSON.parse(document.getElementById("bootstrap").textContent)
+//
+type spaBootstrap struct {
+	Config struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		APIRoot string `json:"api_root"`
+	} `json:"config"`
+
+	// Token to send in the X-CSRF-Token header of API requests that use
+	// the session cookie.
+	CSRFToken string `json:"csrf_token"`
+
+	// The logged-in user, or null.
+	User *model.User `json:"user"`
+}
+
+// SPA serves the entry point of the single-page app (see conf.Config.SPA) to
+// GET requests that accept HTML; anything else is not found.  It is meant to
+// be the fallback for frontend routes.
+func SPA(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if (r.Method != "GET" && r.Method != "HEAD") || !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		NotFound(ctx, w, r)
+		return
+	}
+	if Assets == nil {
+		log.Printf("error: no static files to serve the SPA from")
+		renderError(ctx, w, http.StatusInternalServerError)
+		return
+	}
+
+	page, err := fs.ReadFile(Assets.FS(), conf.C.SPAIndex)
+	if err != nil {
+		log.Printf("error: could not read SPA index name=%q err=%q", conf.C.SPAIndex, err)
+		renderError(ctx, w, http.StatusInternalServerError)
+		return
+	}
+
+	if conf.C.SPABootstrap {
+		var data spaBootstrap
+		data.Config.Name = conf.ProjectName
+		data.Config.Version = conf.Version
+		data.Config.APIRoot = "/api"
+		data.CSRFToken = middleware.CSRFToken(ctx)
+		data.User = auth.FromContext(ctx)
+
+		page, err = injectBootstrap(page, data)
+		if err != nil {
+			log.Printf("error: could not encode SPA bootstrap data err=%q", err)
+			renderError(ctx, w, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != "HEAD" {
+		w.Write(page)
+	}
+}
+
+// injectBootstrap adds the bootstrap data to the page, as a JSON <script>
+// element at the end of the <head> (or the start of the page, if it has no
+// <head>), so that it's available before the app's own scripts run.
+func injectBootstrap(page []byte, data spaBootstrap) ([]byte, error) {
+	js, err := toJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	script := `<script id="` + spaBootstrapID + `" type="application/json">` + string(js) + "</script>\n"
+
+	i := bytes.Index(bytes.ToLower(page), []byte("</head>"))
+	if i < 0 {
+		i = 0
+	}
+
+	out := make([]byte, 0, len(page)+len(script))
+	out = append(out, page[:i]...)
+	out = append(out, script...)
+	out = append(out, page[i:]...)
+	return out, nil
+}
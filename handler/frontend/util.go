@@ -6,12 +6,18 @@ import (
 	"log"
 	"net/http"
 	"path/filepath"
+	"sync"
 
 	"github.com/oxtoacart/bpool"
 	"golang.org/x/net/context"
 
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+	"github.com/andrew-d/go-webapp-skeleton/handler"
 	"github.com/andrew-d/go-webapp-skeleton/handler/frontend/layouts"
 	"github.com/andrew-d/go-webapp-skeleton/handler/frontend/templates"
+	"github.com/andrew-d/go-webapp-skeleton/middleware"
+	"github.com/andrew-d/go-webapp-skeleton/session"
 )
 
 type M map[string]interface{}
@@ -20,69 +26,179 @@ var (
 	// Buffer pool for rendering templates
 	bufpool *bpool.BufferPool
 
-	// Map of templates
+	// Map of templates, by name
+	templatesMu  sync.RWMutex
 	templatesMap map[string]*template.Template
-
-	// Extra functions
-	templateFuncs = template.FuncMap{}
 )
 
 func init() {
 	// Create buffer pool
 	bufpool = bpool.NewBufferPool(64)
+}
 
-	// Get the contents of all layouts.
-	layoutData := make(map[string]string)
-	for _, lname := range layouts.AssetNames() {
-		d, _ := layouts.Asset(lname)
-		layoutData[lname] = string(d)
-	}
-
-	// For each template, we parse it.
-	templatesMap = make(map[string]*template.Template)
+// LoadTemplates parses all templates, and must be called before any page is
+// rendered.  In debug mode, templates are also re-parsed from disk whenever
+// they are rendered (see getTemplate), so changes show up without a restart.
+func LoadTemplates() error {
+	m := make(map[string]*template.Template)
 	for _, aname := range templates.AssetNames() {
-		tname := filepath.Base(aname)
+		tmpl, err := parseTemplate(aname)
+		if err != nil {
+			return err
+		}
+		m[filepath.Base(aname)] = tmpl
+	}
 
-		// Create new template with functions
-		tmpl := template.New(tname).Funcs(templateFuncs)
+	templatesMu.Lock()
+	templatesMap = m
+	templatesMu.Unlock()
+	return nil
+}
 
-		// Get the template's data
-		d, _ := templates.Asset(aname)
+// parseTemplate parses the template with the given asset name, along with all
+// of the layouts.
+func parseTemplate(aname string) (*template.Template, error) {
+	// Create new template with functions
+	tmpl := template.New(filepath.Base(aname)).Funcs(templateFuncs)
 
-		// Parse the main template, then all the layouts.
-		tmpl = template.Must(tmpl.Parse(string(d)))
-		for _, layout := range layouts.AssetNames() {
-			tmpl = template.Must(tmpl.Parse(layoutData[layout]))
+	// Parse the main template, then all the layouts.  Each layout is
+	// parsed as its own named template, so that errors refer to the right
+	// file.
+	d, err := templates.Asset(aname)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmpl.Parse(string(d)); err != nil {
+		return nil, err
+	}
+	for _, lname := range layouts.AssetNames() {
+		d, err := layouts.Asset(lname)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.New(lname).Parse(string(d)); err != nil {
+			return nil, err
 		}
+	}
+
+	return tmpl, nil
+}
 
-		// Insert
-		templatesMap[tname] = tmpl
+// getTemplate returns the template with the given name.  In debug mode, the
+// template is parsed again (from disk, when using debug bindata), so that any
+// parse errors are returned here rather than when the program starts.
+func getTemplate(name string) (*template.Template, error) {
+	if conf.C.IsDebug() {
+		for _, aname := range templates.AssetNames() {
+			if filepath.Base(aname) == name {
+				return parseTemplate(aname)
+			}
+		}
+	} else {
+		templatesMu.RLock()
+		tmpl, ok := templatesMap[name]
+		templatesMu.RUnlock()
+		if ok {
+			return tmpl, nil
+		}
 	}
+
+	return nil, fmt.Errorf("The template %s does not exist", name)
 }
 
 // renderTemplate is a wrapper around template.ExecuteTemplate.  It writes into
 // a bytes.Buffer before writing to the http.ResponseWriter to catch any errors
-// resulting from populating the template.
+// resulting from populating the template.  If an error is returned, nothing
+// has been written, and the caller should send an error page instead.
 func renderTemplate(ctx context.Context, w http.ResponseWriter, name string, data map[string]interface{}) error {
-	// Ensure the template exists in the map.
-	tmpl, ok := templatesMap[name]
-	if !ok {
-		log.Printf("error: template does not exist name=%q", name)
-		return fmt.Errorf("The template %s does not exist", name)
+	return renderTemplateStatus(ctx, w, http.StatusOK, name, data)
+}
+
+// renderTemplateStatus is like renderTemplate, but sends the given status
+// code.
+func renderTemplateStatus(ctx context.Context, w http.ResponseWriter, status int, name string, data map[string]interface{}) error {
+	// Ensure the template exists, and parses.
+	tmpl, err := getTemplate(name)
+	if err != nil {
+		log.Printf("error: could not load template err=%q name=%q", err, name)
+		return err
+	}
+
+	// Bind the functions for this request to a copy of the template.
+	tmpl, err = tmpl.Clone()
+	if err != nil {
+		log.Printf("error: could not clone template err=%q name=%q", err, name)
+		return err
 	}
+	tmpl.Funcs(requestFuncs(ctx))
+
+	// Every template gets some information about the current request.
+	if data == nil {
+		data = M{}
+	}
+	clearFlashes := addRequestData(ctx, data)
 
 	// Create a buffer to temporarily write to and check if any errors were encounted.
 	buf := bufpool.Get()
 	defer bufpool.Put(buf)
 
-	err := tmpl.ExecuteTemplate(buf, "base", data)
+	err = tmpl.ExecuteTemplate(buf, "base", data)
 	if err != nil {
 		log.Printf("error: could not render template err=%q name=%q", err, name)
 		return err
 	}
 
+	// The flash messages are only removed once the page showing them has
+	// rendered, so that they aren't lost if it fails to.
+	if clearFlashes {
+		session.FromContext(ctx).ClearFlashes()
+	}
+
 	// Set the header and write the buffer to the http.ResponseWriter
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
 	buf.WriteTo(w)
 	return nil
 }
+
+// addRequestData adds the values that every template gets to data, unless
+// the handler gave its own values:
+//
+//     CurrentUser    the logged-in user, or nil
+//     Flashes        the flash messages from the session
+//     RequestID      the ID of the current request
+//     Version        the version of the program
+//
+// It returns whether the flash messages came from the session, in which case
+// they should be removed from it once the page is rendered.
+func addRequestData(ctx context.Context, data map[string]interface{}) (clearFlashes bool) {
+	if _, ok := data["CurrentUser"]; !ok {
+		data["CurrentUser"] = auth.FromContext(ctx)
+	}
+	if _, ok := data["Flashes"]; !ok {
+		data["Flashes"] = session.FromContext(ctx).Flashes()
+		clearFlashes = true
+	}
+	if _, ok := data["RequestID"]; !ok {
+		data["RequestID"] = middleware.GetRequestID(ctx)
+	}
+	if _, ok := data["Version"]; !ok {
+		data["Version"] = conf.Version
+	}
+	return clearFlashes
+}
+
+// parseForm parses the form in the request body.  If it can't be parsed, an
+// error page is sent to the client and false is returned.
+func parseForm(ctx context.Context, w http.ResponseWriter, r *http.Request) bool {
+	err := handler.ParseForm(w, r)
+	switch {
+	case err == nil:
+		return true
+	case err == handler.ErrBodyTooLarge:
+		renderError(ctx, w, http.StatusRequestEntityTooLarge)
+	default:
+		renderError(ctx, w, http.StatusBadRequest)
+	}
+	return false
+}
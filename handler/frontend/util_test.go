@@ -0,0 +1,92 @@
+package frontend
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+	"github.com/andrew-d/go-webapp-skeleton/session"
+)
+
+func TestRenderTemplateFlashes(t *testing.T) {
+	// Templates are only read from templatesMap outside debug mode.
+	conf.C = conf.Default()
+	conf.C.Environment = "production"
+	defer func() { conf.C = nil }()
+
+	// The "broken" template fails after it has shown the flash messages.
+	templatesMu.Lock()
+	templatesMap = map[string]*template.Template{
+		"ok.tmpl": template.Must(template.New("ok.tmpl").Funcs(templateFuncs).Parse(
+			`{{ define "base" }}{{ range .Flashes }}{{ .Message }}{{ end }}{{ end }}`)),
+		"broken.tmpl": template.Must(template.New("broken.tmpl").Funcs(templateFuncs).Parse(
+			`{{ define "base" }}{{ range .Flashes }}{{ .Message }}{{ end }}{{ index .Flashes 5 }}{{ end }}`)),
+	}
+	templatesMu.Unlock()
+	defer func() {
+		templatesMu.Lock()
+		templatesMap = nil
+		templatesMu.Unlock()
+	}()
+
+	tests := []struct {
+		name        string
+		wantErr     bool
+		wantFlashes int
+	}{
+		{"broken.tmpl", true, 1},
+		{"ok.tmpl", false, 0},
+	}
+
+	s := session.New()
+	s.AddFlash(session.FlashSuccess, "Saved.")
+	ctx := session.NewContext(context.Background(), s)
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		err := renderTemplate(ctx, w, tt.name, nil)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: renderTemplate returned %v, want error: %t", tt.name, err, tt.wantErr)
+		}
+		if got := len(s.Flashes()); got != tt.wantFlashes {
+			t.Errorf("%s: session has %d flash messages after rendering, want %d", tt.name, got, tt.wantFlashes)
+		}
+		if !tt.wantErr && w.Body.String() != "Saved." {
+			t.Errorf("%s: rendered %q, want %q", tt.name, w.Body.String(), "Saved.")
+		}
+	}
+}
+
+func TestParseForm(t *testing.T) {
+	conf.C = conf.Default()
+	conf.C.Environment = "production"
+	conf.C.MaxBodySize = 32
+	defer func() { conf.C = nil }()
+
+	tests := []struct {
+		body       string
+		wantOK     bool
+		wantStatus int
+	}{
+		{"name=Alice", true, http.StatusOK},
+		{"name=%zz", false, http.StatusBadRequest},
+		{"name=" + strings.Repeat("A", 32), false, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/people", strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		if ok := parseForm(context.Background(), w, r); ok != tt.wantOK {
+			t.Errorf("parseForm(%q) = %t, want %t", tt.body, ok, tt.wantOK)
+		}
+		if w.Code != tt.wantStatus {
+			t.Errorf("parseForm(%q) sent status %d, want %d", tt.body, w.Code, tt.wantStatus)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+)
+
+// PageURL returns the URL of the current request, modified to point at the
+// page for the given cursor.  A nil cursor points at the first page.
+func PageURL(r *http.Request, c *datastore.Cursor) string {
+	u := *r.URL
+	q := u.Query()
+	if c != nil {
+		q.Set("cursor", c.Encode())
+	} else {
+		q.Del("cursor")
+	}
+	u.RawQuery = q.Encode()
+
+	// Only return the path and query, so we don't need to guess the scheme
+	// and host that the client used.
+	u.Scheme, u.Host, u.User = "", "", nil
+	return u.String()
+}
+
+// SetPageHeaders sets the 'Link' header (RFC 5988) with links to the
+// surrounding pages, and the 'X-Total-Count' header if the total is known.
+func SetPageHeaders(w http.ResponseWriter, r *http.Request, page *datastore.Page) {
+	links := []string{
+		fmt.Sprintf(`<%s>; rel="first"`, PageURL(r, nil)),
+	}
+	if page.Prev != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, PageURL(r, page.Prev)))
+	}
+	if page.Next != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, PageURL(r, page.Next)))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+
+	if page.Total >= 0 {
+		w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+var (
+	routesMu sync.RWMutex
+	routes   = make(map[string]string)
+)
+
+// NameRoute gives a name to the route with the given pattern (in the form
+// used by goji.io/pat, e.g. "/people/:person"), so that URLs for it can be
+// built with URL.
+func NameRoute(name, pattern string) {
+	routesMu.Lock()
+	defer routesMu.Unlock()
+
+	if _, ok := routes[name]; ok {
+		panic("handler: route " + name + " is already named")
+	}
+	routes[name] = pattern
+}
+
+// URL returns the path of the named route (see NameRoute), with each of the
+// route's variables replaced by the corresponding parameter, in order:
+//
+//     URL("person_edit", 123)    // "/people/123/edit"
+//
+func URL(name string, params ...interface{}) (string, error) {
+	routesMu.RLock()
+	pattern, ok := routes[name]
+	routesMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("no route named %q", name)
+	}
+
+	segments := strings.Split(pattern, "/")
+	n := 0
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+		if n >= len(params) {
+			return "", fmt.Errorf("route %q needs more than %d parameters", name, len(params))
+		}
+		segments[i] = url.PathEscape(fmt.Sprint(params[n]))
+		n++
+	}
+	if n != len(params) {
+		return "", fmt.Errorf("route %q takes %d parameters, not %d", name, n, len(params))
+	}
+
+	return strings.Join(segments, "/"), nil
+}
@@ -0,0 +1,202 @@
+// Package lifecycle starts and stops the parts of the program in order.
+//
+// Each part registers a Hook, with functions to start and stop it.  Hooks are
+// started in the order they were added, and stopped in the reverse order, so
+// a hook can rely on everything that was added before it:
+//
+//     lc := lifecycle.New()
+//     lc.Append(lifecycle.Hook{
+//         Name: "database",
+//         Stop: func(ctx context.Context) error { return db.Close() },
+//     })
+//     if err := lc.Run(); err != nil {
+//         log.Printf("error: %s", err)
+//     }
+//
+// When the program gets SIGINT or SIGTERM, it is first marked as not ready
+// (see Manager.Readiness), so that load balancers stop sending it requests,
+// and only then are the hooks stopped.
+package lifecycle
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Hook starts and stops one part of the program.  Either function may be nil.
+type Hook struct {
+	// Name of the hook, used in log messages.
+	Name string
+
+	// Start is called when the program starts.  It must not block: anything
+	// long-running should be started in a goroutine.  If Start returns an
+	// error, the hooks that were already started are stopped, and the
+	// program exits.
+	Start func(ctx context.Context) error
+
+	// Stop is called when the program shuts down, and should return once
+	// everything the hook started has finished, or the context is done.
+	Stop func(ctx context.Context) error
+}
+
+// Manager runs a list of hooks.
+type Manager struct {
+	// How long to wait between marking the program as not ready and
+	// stopping the hooks, to give load balancers time to notice.
+	DrainDelay time.Duration
+
+	// How long each hook has to stop.
+	StopTimeout time.Duration
+
+	mu    sync.Mutex
+	hooks []Hook
+
+	ready int32 // accessed atomically
+	exit  chan error
+}
+
+// New returns a manager without any hooks.
+func New() *Manager {
+	return &Manager{
+		StopTimeout: 10 * time.Second,
+		exit:        make(chan error, 1),
+	}
+}
+
+// Append adds a hook, which is started after all the hooks added before it,
+// and stopped before them.
+func (m *Manager) Append(h Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, h)
+}
+
+// Ready returns whether the program is ready to handle requests: every hook
+// has started, and it isn't shutting down.
+func (m *Manager) Ready() bool {
+	return atomic.LoadInt32(&m.ready) == 1
+}
+
+func (m *Manager) setReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&m.ready, v)
+}
+
+// Readiness responds with 200 OK if the program is ready (see Ready), or
+// with 503 Service Unavailable otherwise.  It is meant for load balancers'
+// health checks.
+func (m *Manager) Readiness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !m.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "not ready")
+		return
+	}
+	fmt.Fprintln(w, "ready")
+}
+
+// Exit makes Run shut down, as if the program had got a signal, and return
+// the given error.  It is for hooks that fail after they were started.
+func (m *Manager) Exit(err error) {
+	select {
+	case m.exit <- err:
+	default:
+		// Already shutting down.
+	}
+}
+
+// Run starts every hook, waits for SIGINT or SIGTERM (or a call to Exit),
+// and then shuts down: it marks the program as not ready, waits for
+// DrainDelay, and stops every hook that was started.  A second signal skips
+// the rest of the delay.  Run returns the error that any hook failed to
+// start with, or that was given to Exit.
+func (m *Manager) Run() error {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	m.mu.Lock()
+	hooks := append([]Hook(nil), m.hooks...)
+	m.mu.Unlock()
+
+	started, err := m.start(hooks)
+	if err != nil {
+		m.stop(hooks[:started])
+		return err
+	}
+
+	m.setReady(true)
+	log.Printf("info: ready")
+
+	select {
+	case sig := <-sigs:
+		log.Printf("info: shutting down signal=%q", sig)
+	case err = <-m.exit:
+		log.Printf("error: shutting down err=%q", err)
+	}
+
+	m.setReady(false)
+	if m.DrainDelay > 0 {
+		log.Printf("info: marked as not ready, waiting for requests to drain delay=%q", m.DrainDelay)
+		select {
+		case <-time.After(m.DrainDelay):
+		case sig := <-sigs:
+			log.Printf("warning: not waiting for requests to drain signal=%q", sig)
+		}
+	}
+
+	m.stop(hooks)
+	log.Printf("info: shutdown complete")
+	return err
+}
+
+// start starts the hooks in order, returning how many were started.
+func (m *Manager) start(hooks []Hook) (int, error) {
+	for i, h := range hooks {
+		if h.Start == nil {
+			continue
+		}
+
+		log.Printf("info: starting hook=%q", h.Name)
+		if err := h.Start(context.Background()); err != nil {
+			log.Printf("error: could not start hook=%q err=%q", h.Name, err)
+			return i, fmt.Errorf("could not start %s: %s", h.Name, err)
+		}
+	}
+	return len(hooks), nil
+}
+
+// stop stops the hooks in reverse order.
+func (m *Manager) stop(hooks []Hook) {
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if h.Stop == nil {
+			continue
+		}
+
+		log.Printf("info: stopping hook=%q", h.Name)
+		start := time.Now()
+
+		ctx, cancel := context.WithTimeout(context.Background(), m.StopTimeout)
+		err := h.Stop(ctx)
+		cancel()
+
+		if err != nil {
+			log.Printf("error: could not stop hook=%q err=%q", h.Name, err)
+		} else {
+			log.Printf("info: stopped hook=%q duration=%q", h.Name, time.Since(start))
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// recorder records the order that hooks are started and stopped in.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// hook returns a hook that records when it is started and stopped, and fails
+// to start with startErr if it isn't nil.
+func (r *recorder) hook(name string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			r.record("start %s", name)
+			return startErr
+		},
+		Stop: func(context.Context) error {
+			r.record("stop %s", name)
+			return nil
+		},
+	}
+}
+
+// readiness returns the status code that m.Readiness responds with.
+func readiness(m *Manager) int {
+	w := httptest.NewRecorder()
+	m.Readiness(w, httptest.NewRequest("GET", "/readyz", nil))
+	return w.Code
+}
+
+func TestRun(t *testing.T) {
+	errExit := errors.New("exit")
+	errStart := errors.New("start failed")
+
+	tests := []struct {
+		name       string
+		startErrs  map[string]error
+		exit       error
+		wantEvents []string
+		wantErr    string
+	}{
+		{
+			name: "stops in reverse order",
+			exit: errExit,
+			wantEvents: []string{
+				"start a", "start b", "start c",
+				"stop c", "stop b", "stop a",
+			},
+			wantErr: "exit",
+		},
+		{
+			name: "exit without an error",
+			wantEvents: []string{
+				"start a", "start b", "start c",
+				"stop c", "stop b", "stop a",
+			},
+		},
+		{
+			name:      "start fails",
+			startErrs: map[string]error{"b": errStart},
+			wantEvents: []string{
+				"start a", "start b",
+				"stop a",
+			},
+			wantErr: "could not start b: start failed",
+		},
+		{
+			name:      "first start fails",
+			startErrs: map[string]error{"a": errStart},
+			wantEvents: []string{
+				"start a",
+			},
+			wantErr: "could not start a: start failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			m := New()
+			for _, name := range []string{"a", "b", "c"} {
+				m.Append(r.hook(name, tt.startErrs[name]))
+			}
+
+			// Exit can be called before Run, in which case Run shuts
+			// down as soon as every hook has started.
+			m.Exit(tt.exit)
+
+			err := m.Run()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Run returned %q, want no error", err)
+			} else if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("Run returned %v, want %q", err, tt.wantErr)
+			}
+			if got := r.Events(); !reflect.DeepEqual(got, tt.wantEvents) {
+				t.Errorf("events = %q, want %q", got, tt.wantEvents)
+			}
+			if m.Ready() {
+				t.Errorf("Ready() = true after Run returned, want false")
+			}
+		})
+	}
+}
+
+func TestRunNilFunctions(t *testing.T) {
+	r := &recorder{}
+	m := New()
+	m.Append(r.hook("a", nil))
+	m.Append(Hook{Name: "no start", Stop: func(context.Context) error {
+		r.record("stop no start")
+		return nil
+	}})
+	m.Append(Hook{Name: "nothing"})
+	m.Exit(nil)
+
+	if err := m.Run(); err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+	want := []string{"start a", "stop no start", "stop a"}
+	if got := r.Events(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	r := &recorder{}
+	m := New()
+	m.DrainDelay = 10 * time.Millisecond
+	m.Append(Hook{
+		Name: "server",
+		Start: func(context.Context) error {
+			r.record("starting: %d", readiness(m))
+
+			// Once every hook has started, check that we're ready
+			// and shut down.
+			go func() {
+				for !m.Ready() {
+					time.Sleep(time.Millisecond)
+				}
+				r.record("running: %d", readiness(m))
+				m.Exit(nil)
+			}()
+			return nil
+		},
+		Stop: func(context.Context) error {
+			r.record("stopping: %d", readiness(m))
+			return nil
+		},
+	})
+
+	if err := m.Run(); err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+	want := []string{
+		fmt.Sprintf("starting: %d", http.StatusServiceUnavailable),
+		fmt.Sprintf("running: %d", http.StatusOK),
+		fmt.Sprintf("stopping: %d", http.StatusServiceUnavailable),
+	}
+	if got := r.Events(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	r := &recorder{}
+	m := New()
+	m.StopTimeout = 10 * time.Millisecond
+	m.Append(r.hook("a", nil))
+	m.Append(Hook{
+		Name: "slow",
+		Stop: func(ctx context.Context) error {
+			<-ctx.Done()
+			r.record("stop slow: %s", ctx.Err())
+			return ctx.Err()
+		},
+	})
+	m.Append(Hook{
+		Name: "deadline",
+		Stop: func(ctx context.Context) error {
+			// Each hook gets its own timeout.
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				t.Errorf("hook context has no deadline")
+			} else if d := time.Until(deadline); d <= 0 || d > m.StopTimeout {
+				t.Errorf("hook context expires in %s, want at most %s", d, m.StopTimeout)
+			}
+			return nil
+		},
+	})
+	m.Exit(nil)
+
+	done := make(chan error, 1)
+	go func() { done <- m.Run() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after a hook timed out")
+	}
+
+	// The hook that timed out mustn't stop the ones before it from being
+	// stopped.
+	got := r.Events()
+	want := []string{"start a", "stop slow: " + context.DeadlineExceeded.Error(), "stop a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package lifecycle
+
+import (
+	"log"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Ticker returns a hook for a background worker that calls fn every
+// interval, from when the hook is started until it is stopped.  Errors from
+// fn are logged.  The context given to fn is cancelled when the hook is
+// stopped, and stopping waits for a call that's in progress to return.
+func Ticker(name string, interval time.Duration, fn func(ctx context.Context) error) Hook {
+	var (
+		cancel context.CancelFunc
+		done   chan struct{}
+	)
+
+	return Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			done = make(chan struct{})
+
+			go func() {
+				defer close(done)
+
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ticker.C:
+						if err := fn(ctx); err != nil {
+							log.Printf("error: background worker failed worker=%q err=%q", name, err)
+						}
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
-	"bytes"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/tylerb/graceful"
@@ -11,89 +13,158 @@ import (
 	"goji.io/pat"
 	"golang.org/x/net/context"
 
+	"github.com/andrew-d/go-webapp-skeleton/auth"
 	"github.com/andrew-d/go-webapp-skeleton/conf"
 	"github.com/andrew-d/go-webapp-skeleton/datastore"
 	"github.com/andrew-d/go-webapp-skeleton/datastore/database"
+	"github.com/andrew-d/go-webapp-skeleton/handler/frontend"
+	"github.com/andrew-d/go-webapp-skeleton/lifecycle"
 	"github.com/andrew-d/go-webapp-skeleton/middleware"
 	"github.com/andrew-d/go-webapp-skeleton/router"
+	"github.com/andrew-d/go-webapp-skeleton/session"
 	"github.com/andrew-d/go-webapp-skeleton/static"
 )
 
 func main() {
+	// Load the configuration.
+	c, args, err := conf.Load(&conf.Options{
+		Env:  os.Environ(),
+		Args: os.Args[1:],
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+		os.Exit(2)
+	}
+	conf.C = c
+	conf.ConfigureLogging(conf.C)
+
+	// The 'migrate' and 'roles' subcommands manage the database without
+	// starting the server.
+	if len(args) > 0 && args[0] == "migrate" {
+		os.Exit(runMigrate(args[1:]))
+	} else if len(args) > 0 && args[0] == "roles" {
+		os.Exit(runRoles(args[1:]))
+	} else if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: unknown command %q\n", os.Args[0], args[0])
+		os.Exit(2)
+	}
+
 	log.Printf("initializing project_name=%q version=%q revision=%q",
 		conf.ProjectName,
 		conf.Version,
 		conf.Revision)
 
+	// Parse the templates.  In debug mode, templates are re-parsed whenever
+	// they are used, so errors are shown as an error page instead.
+	if err := frontend.LoadTemplates(); err != nil {
+		if !conf.C.IsDebug() {
+			log.Printf("error: could not parse templates err=%q", err)
+			os.Exit(1)
+		}
+		log.Printf("warning: could not parse templates err=%q", err)
+	}
+
 	// Connect to the database.
 	db, err := database.Connect(conf.C.DbType, conf.C.DbConn)
 	if err != nil {
-		log.Printf("could not connect to database err=%q db_type=%q db_conn=%q",
+		log.Printf("error: could not connect to database err=%q db_type=%q db_conn=%q",
 			err,
 			conf.C.DbType,
 			conf.C.DbConn)
-		return
+		os.Exit(1)
 	}
 
+	// Everything from here on is started in order and stopped in reverse
+	// order by the lifecycle manager, when we're told to shut down.
+	lc := lifecycle.New()
+	lc.DrainDelay = time.Duration(conf.C.ShutdownDrain) * time.Second
+	lc.StopTimeout = time.Duration(conf.C.ShutdownTimeout) * time.Second
+	lc.Append(lifecycle.Hook{
+		Name: "database",
+		Stop: func(context.Context) error { return db.Close() },
+	})
+
 	// Create datastore.
 	ds := database.NewDatastore(db)
 
+	// Create session store.
+	sessionStore, err := session.NewStore(conf.C, db)
+	if err != nil {
+		log.Printf("error: could not create session store err=%q", err)
+		db.Close()
+		os.Exit(1)
+	}
+
+	// Expired sessions are removed from the database in the background.
+	if store, ok := sessionStore.(*session.DBStore); ok {
+		lc.Append(lifecycle.Ticker("session cleanup", time.Hour, func(context.Context) error {
+			return store.DeleteExpired()
+		}))
+	}
+
 	// Create API router and add middleware.
 	apiMux := router.API()
-	apiMux.Use(middleware.Options)
+	apiMux.UseC(middleware.JSONRecoverer)
+	apiMux.UseC(middleware.CORS(conf.C, apiMux.Methods))
 	apiMux.Use(middleware.JSON)
+	apiMux.Use(middleware.NoCache)
+	apiMux.Use(middleware.Compress)
+	apiMux.UseC(session.Middleware(sessionStore))
+	apiMux.UseC(auth.Middleware)
+	apiMux.UseC(middleware.BearerAuth)
+	apiMux.UseC(middleware.CSRF)
 
 	// Create web router.
 	webMux := router.Web()
-
-	// Create root mux and add common middleware.
+	webMux.Use(middleware.NoCache)
+	webMux.Use(middleware.Compress)
+	webMux.UseC(session.Middleware(sessionStore))
+	webMux.UseC(auth.Middleware)
+	webMux.UseC(middleware.CSRF)
+
+	// Create root mux and add common middleware.  Sessions and the current
+	// user are only loaded by the API and web routers, so that static files
+	// and health checks don't need the database.  Panics anywhere but the
+	// API router, which recovers from its own with a JSON error, get an
+	// error page.
 	rootMux := goji.NewMux()
 	rootMux.UseC(middleware.RequestID)
 	rootMux.UseC(middleware.Logger)
 	rootMux.UseC(middleware.Recoverer)
 	rootMux.Use(middleware.SetHeaders)
 
-	// Serve all static assets from the root.
-	serveAssetAt := func(asset, path string) {
-		info, _ := static.AssetInfo(asset)
-		modTime := info.ModTime()
-		data := static.MustAsset(asset)
-
-		webMux.Handle(pat.Get(path), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			log.Printf("debug: serving asset: %s", asset)
-			http.ServeContent(w, r, asset, modTime, bytes.NewReader(data))
-		}))
+	// Serve static files.  They set their own caching and compression
+	// headers, so they're added to the root mux rather than the web router.
+	staticFiles := static.FS()
+	if conf.C.StaticDir != "" {
+		staticFiles = os.DirFS(conf.C.StaticDir)
 	}
-	for _, asset := range static.AssetNames() {
-		log.Printf("debug: adding route for asset: %s", asset)
-		serveAssetAt(asset, "/static/"+asset)
-	}
-
-	// Special case a bunch of assets that should be served from the root.
-	for _, asset := range []string{
-		"clientaccesspolicy.xml",
-		"crossdomain.xml",
-		"favicon.ico",
-		"humans.txt",
-		"robots.txt",
-	} {
-		// Note: only serve if we have this asset.
-		if _, err := static.Asset(asset); err == nil {
-			log.Printf("debug: adding special route for asset: %s", asset)
-			serveAssetAt(asset, "/"+asset)
+	frontend.Assets = router.Static(rootMux, staticFiles, conf.C.SPA)
+
+	// Link to the embedded files by the fingerprinted names in the manifest
+	// that was written when they were built.  Files in static_dir, and the
+	// embedded files in debug mode (which are read from disk), can change
+	// while we're running, so they are hashed when they're linked to.
+	if conf.C.StaticDir == "" && !conf.C.IsDebug() {
+		if err := frontend.Assets.LoadManifest(); err != nil {
+			log.Printf("warning: could not load static file manifest, hashing files instead err=%q", err)
 		}
 	}
 
-	// Serve the index page if we have one.
-	for _, asset := range []string{"index.html", "index.htm"} {
-		// Note: only serve if we have this asset, and only serve the first
-		// option.
-		if _, err := static.Asset(asset); err == nil {
-			log.Printf("debug: adding index route for asset: %s", asset)
-			serveAssetAt(asset, "/")
-			break
-		}
+	// Anything else on the web router is a page of the single-page app, if
+	// we have one, or not found.  This must be added after its other
+	// routes, since routes are matched in order.
+	if conf.C.SPA {
+		webMux.HandleFuncC(pat.Get("/*"), frontend.SPA)
 	}
+	webMux.HandleFuncC(pat.New("/*"), frontend.NotFound)
+
+	// Frontend errors from middleware get the same error pages.
+	middleware.ErrorPage = frontend.ErrorPage
+
+	// Load balancers can check whether we're ready for requests, which we
+	// aren't once we start shutting down.
+	rootMux.Handle(pat.Get("/readyz"), http.HandlerFunc(lc.Readiness))
 
 	// Mount the API/Web muxes last (since order matters).
 	rootMux.HandleC(pat.New("/api/*"), apiMux)
@@ -108,8 +179,55 @@ func main() {
 		rootMux.ServeHTTPC(ctx, w, r)
 	})
 
-	// Start serving
-	log.Printf("starting server on: %s", conf.C.HostString())
-	graceful.Run(conf.C.HostString(), 10*time.Second, outer)
+	// Start serving.  The server is added last, so that it's stopped first.
+	srv := &graceful.Server{
+		Server:           &http.Server{Addr: conf.C.HostString(), Handler: outer},
+		Timeout:          lc.StopTimeout,
+		NoSignalHandling: true,
+	}
+	lc.Append(serverHook(lc, srv))
+
+	if err := lc.Run(); err != nil {
+		log.Printf("error: server failed err=%q", err)
+		os.Exit(1)
+	}
 	log.Printf("server finished")
 }
+
+// serverHook returns a hook that serves HTTP with srv.  Stopping it stops
+// accepting connections, and waits for requests in progress to finish for up
+// to srv.Timeout.
+func serverHook(lc *lifecycle.Manager, srv *graceful.Server) lifecycle.Hook {
+	var stopped <-chan struct{}
+
+	return lifecycle.Hook{
+		Name: "http server",
+		Start: func(context.Context) error {
+			// Listen here, rather than in Serve, so that errors
+			// (e.g. the port being in use) stop the program from
+			// starting.
+			l, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			stopped = srv.StopChan()
+
+			log.Printf("starting server on: %s", srv.Addr)
+			go func() {
+				if err := srv.Serve(l); err != nil {
+					lc.Exit(err)
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			srv.Stop(srv.Timeout)
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
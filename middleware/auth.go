@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"goji.io"
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/handler/apierror"
+)
+
+// BearerAuth authenticates API requests that have an API token in the
+// 'Authorization' header:
+//
+//     Authorization: Bearer <token>
+//
+// The token's user and the token itself are put in the context (see
+// auth.FromContext and auth.TokenFromContext), replacing any user from the
+// session.  Requests with an invalid token are rejected with a 401; requests
+// without an 'Authorization' header are passed through unchanged.
+func BearerAuth(h goji.Handler) goji.Handler {
+	fn := func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			h.ServeHTTPC(ctx, w, r)
+			return
+		}
+
+		const prefix = "bearer "
+		if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+			unauthorized(ctx, w, `Bearer error="invalid_request"`, "invalid Authorization header")
+			return
+		}
+
+		user, token, err := auth.AuthenticateToken(ctx, strings.TrimSpace(header[len(prefix):]))
+		if err == auth.ErrInvalidToken {
+			unauthorized(ctx, w, `Bearer error="invalid_token"`, err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("error: could not authenticate token err=%q", err)
+			e := apierror.FromError(err)
+			e.RequestID = GetRequestID(ctx)
+			apierror.Write(w, e)
+			return
+		}
+
+		ctx = auth.NewContext(ctx, user)
+		ctx = auth.NewTokenContext(ctx, token)
+		h.ServeHTTPC(ctx, w, r)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// RequireUser rejects API requests that aren't from a logged-in user, or
+// authenticated with an API token, with a 401.
+func RequireUser(h goji.Handler) goji.Handler {
+	fn := func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if auth.FromContext(ctx) == nil {
+			unauthorized(ctx, w, "Bearer", "authentication required")
+			return
+		}
+		h.ServeHTTPC(ctx, w, r)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// unauthorized sends a 401 error with the given challenge.
+func unauthorized(ctx context.Context, w http.ResponseWriter, challenge, message string) {
+	w.Header().Set("WWW-Authenticate", challenge)
+	e := apierror.New(http.StatusUnauthorized, message)
+	e.RequestID = GetRequestID(ctx)
+	apierror.Write(w, e)
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"goji.io"
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+// testStore is a datastore with one user (ID 1), who has the API token
+// "secret-token" and the "editor" role.  The token "orphan-token" belongs to
+// a user that doesn't exist.  If err is set, every method returns it.  Other
+// methods panic.
+type testStore struct {
+	datastore.Datastore
+	err error
+}
+
+func (s *testStore) GetTokenByHash(hash string) (*model.Token, error) {
+	switch {
+	case s.err != nil:
+		return nil, s.err
+	case hash == auth.HashToken("secret-token"):
+		return &model.Token{ID: 1, UserID: 1}, nil
+	case hash == auth.HashToken("orphan-token"):
+		return &model.Token{ID: 2, UserID: 2}, nil
+	}
+	return nil, datastore.ErrNotFound
+}
+
+func (s *testStore) GetUser(id int64) (*model.User, error) {
+	switch {
+	case s.err != nil:
+		return nil, s.err
+	case id == 1:
+		return &model.User{ID: 1}, nil
+	}
+	return nil, datastore.ErrNotFound
+}
+
+func (s *testStore) ListUserRoles(userID int64) ([]string, error) {
+	switch {
+	case s.err != nil:
+		return nil, s.err
+	case userID == 1:
+		return []string{"editor"}, nil
+	}
+	return []string{}, nil
+}
+
+// whoami responds with the ID of the logged-in user, and of the API token
+// that the request was authenticated with, in the X-User and X-Token headers.
+var whoami = goji.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if user := auth.FromContext(ctx); user != nil {
+		w.Header().Set("X-User", strconv.FormatInt(user.ID, 10))
+	}
+	if token := auth.TokenFromContext(ctx); token != nil {
+		w.Header().Set("X-Token", strconv.FormatInt(token.ID, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestBearerAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        string
+		err           error
+		wantStatus    int
+		wantChallenge string
+		wantUser      string
+		wantToken     string
+	}{
+		{name: "no header", wantStatus: http.StatusOK},
+		{name: "valid token", header: "Bearer secret-token", wantStatus: http.StatusOK, wantUser: "1", wantToken: "1"},
+		{name: "scheme is case-insensitive", header: "bearer secret-token", wantStatus: http.StatusOK, wantUser: "1", wantToken: "1"},
+		{name: "other scheme", header: "Basic c2VjcmV0", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer error="invalid_request"`},
+		{name: "no token", header: "Bearer ", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer error="invalid_request"`},
+		{name: "unknown token", header: "Bearer wrong-token", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer error="invalid_token"`},
+		{name: "token of a deleted user", header: "Bearer orphan-token", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer error="invalid_token"`},
+		{name: "datastore error", header: "Bearer secret-token", err: errors.New("broken"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		ctx := datastore.NewContext(context.Background(), &testStore{err: tt.err})
+
+		// A user from the session is replaced by the token's user.
+		ctx = auth.NewContext(ctx, &model.User{ID: 99})
+		if tt.header == "" {
+			tt.wantUser = "99"
+		}
+
+		r := httptest.NewRequest("GET", "/api/people", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		BearerAuth(whoami).ServeHTTPC(ctx, w, r)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+		if got := w.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+			t.Errorf("%s: WWW-Authenticate = %q, want %q", tt.name, got, tt.wantChallenge)
+		}
+		if got := w.Header().Get("X-User"); got != tt.wantUser {
+			t.Errorf("%s: user = %q, want %q", tt.name, got, tt.wantUser)
+		}
+		if got := w.Header().Get("X-Token"); got != tt.wantToken {
+			t.Errorf("%s: token = %q, want %q", tt.name, got, tt.wantToken)
+		}
+	}
+}
+
+func TestRequireUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       *model.User
+		wantStatus int
+	}{
+		{"logged in", &model.User{ID: 1}, http.StatusOK},
+		{"not logged in", nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.user != nil {
+			ctx = auth.NewContext(ctx, tt.user)
+		}
+
+		w := httptest.NewRecorder()
+		RequireUser(whoami).ServeHTTPC(ctx, w, httptest.NewRequest("GET", "/api/tokens", nil))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Responses smaller than this are not compressed, since the gzip overhead
+// outweighs any savings.
+const compressMinSize = 1024
+
+// Content types that Compress compresses.  Other types, such as images, are
+// usually compressed already.
+var compressTypes = map[string]bool{
+	"application/javascript": true,
+	"application/json":       true,
+	"application/xml":        true,
+	"image/svg+xml":          true,
+	"text/css":               true,
+	"text/html":              true,
+	"text/javascript":        true,
+	"text/plain":             true,
+	"text/xml":               true,
+}
+
+// Compress gzips responses for clients that accept it, if they are at least
+// 1 KiB long and of a compressible type (such as HTML or JSON).  It is meant
+// for dynamic responses; static assets are compressed ahead of time.
+func Compress(h http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == "HEAD" || !AcceptsEncoding(r, "gzip") {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		// Not deferred: if the handler panics, anything buffered is
+		// discarded so that the Recoverer can send an error instead.
+		cw := &compressWriter{ResponseWriter: w}
+		h.ServeHTTP(cw, r)
+		cw.Close()
+	}
+	return http.HandlerFunc(fn)
+}
+
+// compressWriter buffers the start of a response until it knows whether to
+// compress it: either compressMinSize bytes have been written, or the
+// response is finished.
+type compressWriter struct {
+	http.ResponseWriter
+	code    int
+	buf     bytes.Buffer
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (c *compressWriter) WriteHeader(code int) {
+	if c.code == 0 {
+		c.code = code
+	}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if c.code == 0 {
+		c.code = http.StatusOK
+	}
+	if !c.decided {
+		c.buf.Write(p)
+		if c.buf.Len() >= compressMinSize {
+			if err := c.start(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+
+	if c.gz != nil {
+		return c.gz.Write(p)
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// start sends the response headers, compressing the response if it is long
+// enough and of the right type, followed by everything buffered so far.
+func (c *compressWriter) start(long bool) error {
+	c.decided = true
+	if c.code == 0 {
+		c.code = http.StatusOK
+	}
+
+	hdr := c.Header()
+	if hdr.Get("Content-Type") == "" && c.buf.Len() > 0 {
+		hdr.Set("Content-Type", http.DetectContentType(c.buf.Bytes()))
+	}
+	if long && c.shouldCompress() {
+		hdr.Set("Content-Encoding", "gzip")
+		hdr.Del("Content-Length")
+		hdr.Del("Accept-Ranges")
+		c.gz = gzip.NewWriter(c.ResponseWriter)
+	}
+	c.ResponseWriter.WriteHeader(c.code)
+
+	if c.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if c.gz != nil {
+		_, err = c.gz.Write(c.buf.Bytes())
+	} else {
+		_, err = c.ResponseWriter.Write(c.buf.Bytes())
+	}
+	c.buf.Reset()
+	return err
+}
+
+func (c *compressWriter) shouldCompress() bool {
+	hdr := c.Header()
+	if hdr.Get("Content-Encoding") != "" || hdr.Get("Content-Range") != "" {
+		return false
+	}
+	if c.code < 200 || c.code == http.StatusNoContent || c.code == http.StatusNotModified ||
+		c.code == http.StatusPartialContent {
+		return false
+	}
+	if n, err := strconv.Atoi(hdr.Get("Content-Length")); err == nil && n < compressMinSize {
+		return false
+	}
+
+	typ := hdr.Get("Content-Type")
+	if i := strings.Index(typ, ";"); i >= 0 {
+		typ = typ[:i]
+	}
+	return compressTypes[strings.TrimSpace(strings.ToLower(typ))]
+}
+
+// Flush sends everything written so far to the client.
+func (c *compressWriter) Flush() {
+	if !c.decided {
+		c.start(c.buf.Len() >= compressMinSize)
+	}
+	if c.gz != nil {
+		c.gz.Flush()
+	}
+	if fl, ok := c.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
+
+// Close finishes the response.
+func (c *compressWriter) Close() error {
+	if !c.decided {
+		if c.code == 0 {
+			// Nothing was written, so let the server send its default
+			// response.
+			return nil
+		}
+		return c.start(false)
+	}
+	if c.gz != nil {
+		return c.gz.Close()
+	}
+	return nil
+}
+
+// AcceptsEncoding returns whether the client accepts responses in the given
+// content coding (e.g. "gzip"), according to the request's Accept-Encoding
+// header.
+func AcceptsEncoding(r *http.Request, coding string) bool {
+	accepted := false
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, q := part, ""
+		if i := strings.Index(part, ";"); i >= 0 {
+			name, q = part[:i], part[i+1:]
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != coding && name != "*" {
+			continue
+		}
+
+		// A quality of zero means the coding is not acceptable.  An
+		// explicit entry for the coding overrides "*".
+		ok := true
+		if q = strings.TrimSpace(q); strings.HasPrefix(q, "q=") {
+			if v, err := strconv.ParseFloat(q[2:], 64); err == nil && v == 0 {
+				ok = false
+			}
+		}
+		if name == coding {
+			return ok
+		}
+		accepted = ok
+	}
+	return accepted
+}
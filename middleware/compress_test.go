@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	long := strings.Repeat("<p>Hello, world!</p>\n", 100)
+	short := "<p>Hello, world!</p>\n"
+
+	tests := []struct {
+		name            string
+		method          string
+		acceptEncoding  string
+		status          int
+		contentType     string
+		contentEncoding string
+		body            string
+		wantGzip        bool
+	}{
+		{name: "long HTML", acceptEncoding: "gzip", body: long, wantGzip: true},
+		{name: "long JSON", acceptEncoding: "gzip", contentType: "application/json; charset=utf-8", body: long, wantGzip: true},
+		{name: "detected content type", acceptEncoding: "gzip", contentType: "-", body: long, wantGzip: true},
+		{name: "below the threshold", acceptEncoding: "gzip", body: short},
+		{name: "exactly the threshold", acceptEncoding: "gzip", body: long[:compressMinSize], wantGzip: true},
+		{name: "not accepted", body: long},
+		{name: "refused", acceptEncoding: "gzip;q=0, deflate", body: long},
+		{name: "HEAD", method: "HEAD", acceptEncoding: "gzip"},
+		{name: "no content", acceptEncoding: "gzip", status: http.StatusNoContent},
+		{name: "not modified", acceptEncoding: "gzip", status: http.StatusNotModified},
+		{name: "error page", acceptEncoding: "gzip", status: http.StatusNotFound, body: long, wantGzip: true},
+		{name: "already encoded", acceptEncoding: "gzip", contentEncoding: "br", body: long},
+		{name: "incompressible type", acceptEncoding: "gzip", contentType: "image/png", body: long},
+	}
+
+	for _, tt := range tests {
+		h := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch tt.contentType {
+			case "":
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			case "-":
+				// Let the middleware detect it.
+			default:
+				w.Header().Set("Content-Type", tt.contentType)
+			}
+			if tt.contentEncoding != "" {
+				w.Header().Set("Content-Encoding", tt.contentEncoding)
+			}
+			if tt.status != 0 {
+				w.WriteHeader(tt.status)
+			}
+			// Write in small pieces, to check that they're
+			// buffered until the threshold is reached.
+			for i := 0; i < len(tt.body); i += 100 {
+				end := i + 100
+				if end > len(tt.body) {
+					end = len(tt.body)
+				}
+				io.WriteString(w, tt.body[i:end])
+			}
+		}))
+
+		method := tt.method
+		if method == "" {
+			method = "GET"
+		}
+		r := httptest.NewRequest(method, "/", nil)
+		if tt.acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		wantStatus := tt.status
+		if wantStatus == 0 {
+			wantStatus = http.StatusOK
+		}
+		if w.Code != wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, wantStatus)
+		}
+		if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("%s: Vary = %q, want %q", tt.name, got, "Accept-Encoding")
+		}
+
+		gzipped := w.Header().Get("Content-Encoding") == "gzip"
+		if gzipped != tt.wantGzip {
+			t.Errorf("%s: gzipped = %t, want %t (Content-Encoding %q)", tt.name, gzipped, tt.wantGzip, w.Header().Get("Content-Encoding"))
+		}
+
+		body := w.Body.String()
+		if gzipped {
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Errorf("%s: invalid gzip response: %s", tt.name, err)
+				continue
+			}
+			b, err := io.ReadAll(zr)
+			if err != nil {
+				t.Errorf("%s: invalid gzip response: %s", tt.name, err)
+				continue
+			}
+			body = string(b)
+		}
+		if body != tt.body {
+			t.Errorf("%s: body is %d bytes, want %d", tt.name, len(body), len(tt.body))
+		}
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, gzip", true},
+		{"gzip;q=0.5", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.0", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"*, gzip;q=0", false},
+		{"*;q=0, gzip", true},
+		{"br", false},
+		{"x-gzip", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", tt.header)
+		if got := AcceptsEncoding(r, "gzip"); got != tt.want {
+			t.Errorf("AcceptsEncoding(%q, gzip) = %t, want %t", tt.header, got, tt.want)
+		}
+	}
+}
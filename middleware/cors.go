@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"goji.io"
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+)
+
+// CORS returns a middleware that applies the cross-origin policy from the
+// given configuration (see conf.Config.CORSOrigins).  The methods function
+// returns the methods that the routes for a request's path accept; it is
+// used to answer OPTIONS requests, including CORS preflight requests.
+//
+// Responses to requests from an allowed origin get the appropriate
+// 'Access-Control-*' headers; other responses get none.
+func CORS(c *conf.Config, methods func(context.Context, *http.Request) []string) func(goji.Handler) goji.Handler {
+	return func(h goji.Handler) goji.Handler {
+		fn := func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			allowOrigin := origin != "" && originAllowed(c.CORSOrigins, origin)
+
+			if origin != "" {
+				w.Header().Add("Vary", "Origin")
+			}
+			if allowOrigin {
+				setOrigin(c, w, origin)
+				if len(c.CORSExposeHeaders) > 0 {
+					w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.CORSExposeHeaders, ", "))
+				}
+			}
+
+			if r.Method != "OPTIONS" {
+				h.ServeHTTPC(ctx, w, r)
+				return
+			}
+
+			// Answer OPTIONS requests with the methods the path accepts,
+			// and let the 'not found' route handle paths that don't exist.
+			allowed := methods(ctx, r)
+			if len(allowed) == 0 {
+				h.ServeHTTPC(ctx, w, r)
+				return
+			}
+			allowed = append(allowed, "OPTIONS")
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+
+			reqMethod := r.Header.Get("Access-Control-Request-Method")
+			if allowOrigin && reqMethod != "" {
+				corsMethods := corsAllowedMethods(c, allowed)
+				if containsFold(corsMethods, reqMethod) {
+					w.Header().Set("Access-Control-Allow-Methods", strings.Join(corsMethods, ", "))
+					if len(c.CORSHeaders) > 0 {
+						w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.CORSHeaders, ", "))
+					}
+					if c.CORSMaxAge > 0 {
+						w.Header().Set("Access-Control-Max-Age", strconv.Itoa(c.CORSMaxAge))
+					}
+				}
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return goji.HandlerFunc(fn)
+	}
+}
+
+// setOrigin sets the 'Access-Control-Allow-Origin' header (and, if enabled,
+// 'Access-Control-Allow-Credentials') for a request from an allowed origin.
+func setOrigin(c *conf.Config, w http.ResponseWriter, origin string) {
+	if containsFold(c.CORSOrigins, "*") {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.CORSCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// corsAllowedMethods returns the methods that can be used in a cross-origin
+// request to a path that accepts the given methods.
+func corsAllowedMethods(c *conf.Config, allowed []string) []string {
+	if len(c.CORSMethods) == 0 {
+		return allowed
+	}
+
+	var methods []string
+	for _, m := range allowed {
+		if containsFold(c.CORSMethods, m) {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// originAllowed returns whether the origin matches one of the given patterns.
+// A pattern of "*" matches any origin, and a pattern such as
+// "https://*.example.com" matches any subdomain of example.com (but not
+// example.com itself).
+func originAllowed(patterns []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+
+		i := strings.Index(p, "*")
+		switch {
+		case p == "*":
+			return true
+		case i < 0:
+			if p == origin {
+				return true
+			}
+		default:
+			prefix, suffix := p[:i], p[i+1:]
+			if len(origin) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) &&
+				strings.HasSuffix(origin, suffix) &&
+				!strings.ContainsAny(origin[len(prefix):len(origin)-len(suffix)], "/:") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+)
+
+func TestCORS(t *testing.T) {
+	c := conf.Default()
+	c.CORSOrigins = []string{"https://app.example.com", "https://*.example.org"}
+	c.CORSMethods = []string{"GET", "POST"}
+	c.CORSCredentials = true
+
+	wildcard := conf.Default()
+	wildcard.CORSOrigins = []string{"*"}
+
+	// Every path in the test accepts GET, POST and DELETE, except
+	// /missing.
+	methods := func(ctx context.Context, r *http.Request) []string {
+		if r.URL.Path == "/missing" {
+			return nil
+		}
+		return []string{"GET", "POST", "DELETE"}
+	}
+
+	tests := []struct {
+		name          string
+		conf          *conf.Config
+		method        string
+		path          string
+		origin        string
+		requestMethod string
+		wantStatus    int
+		wantHeaders   map[string]string
+	}{
+		{
+			name:       "same origin",
+			method:     "GET",
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "",
+			},
+		},
+		{
+			name:       "allowed origin",
+			method:     "GET",
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "Link, X-Total-Count, X-CSRF-Token",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:       "allowed subdomain",
+			method:     "GET",
+			origin:     "https://api.example.org",
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://api.example.org",
+			},
+		},
+		{
+			name:       "disallowed origin",
+			method:     "GET",
+			origin:     "https://evil.example.net",
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "",
+				"Access-Control-Allow-Credentials": "",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:       "wildcard doesn't match the bare domain",
+			method:     "GET",
+			origin:     "https://example.org",
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:       "wildcard doesn't match other ports",
+			method:     "GET",
+			origin:     "https://api.example.org:8443",
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:          "preflight",
+			method:        "OPTIONS",
+			origin:        "https://app.example.com",
+			requestMethod: "POST",
+			wantStatus:    http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "Authorization, Content-Type, X-CSRF-Token",
+				"Access-Control-Max-Age":       "600",
+				"Allow":                        "GET, POST, DELETE, OPTIONS",
+			},
+		},
+		{
+			name:          "preflight for a disallowed method",
+			method:        "OPTIONS",
+			origin:        "https://app.example.com",
+			requestMethod: "DELETE",
+			wantStatus:    http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Methods": "",
+				"Allow":                        "GET, POST, DELETE, OPTIONS",
+			},
+		},
+		{
+			name:          "preflight from a disallowed origin",
+			method:        "OPTIONS",
+			origin:        "https://evil.example.net",
+			requestMethod: "POST",
+			wantStatus:    http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			name:          "preflight for a missing route",
+			method:        "OPTIONS",
+			path:          "/missing",
+			origin:        "https://app.example.com",
+			requestMethod: "POST",
+			wantStatus:    http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Methods": "",
+				"Allow":                        "",
+			},
+		},
+		{
+			name:       "any origin, without credentials",
+			conf:       wildcard,
+			method:     "GET",
+			origin:     "https://anything.example.net",
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		cfg := tt.conf
+		if cfg == nil {
+			cfg = c
+		}
+		path := tt.path
+		if path == "" {
+			path = "/api/people"
+		}
+
+		r := httptest.NewRequest(tt.method, path, nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if tt.requestMethod != "" {
+			r.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+		}
+		w := httptest.NewRecorder()
+		CORS(cfg, methods)(ok).ServeHTTPC(context.Background(), w, r)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+		for name, want := range tt.wantHeaders {
+			if got := w.Header().Get(name); got != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, name, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"log"
+	"net/http"
+
+	"goji.io"
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+	"github.com/andrew-d/go-webapp-skeleton/session"
+)
+
+const (
+	// Name of the form field and header that hold the CSRF token.
+	CSRFField  = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+
+	// Session key that holds the random seed that CSRF tokens are derived
+	// from.
+	csrfSessionKey = "csrf_seed"
+
+	csrfLength = sha256.Size
+)
+
+// CSRF rejects requests with unsafe methods (anything other than GET, HEAD,
+// OPTIONS and TRACE) that don't have a valid CSRF token in the 'csrf_token'
+// form field or the 'X-CSRF-Token' header.  Tokens are obtained from
+// CSRFToken, and are tied to the session; they are derived from the session
+// secret, so rotating the secret (see conf.Config.PreviousSessionSecrets)
+// keeps existing tokens valid.
+//
+// API requests that are authenticated with an API token, or that have no
+// logged-in user at all, are exempt, since they can't carry a victim's
+// session.  CSRF must run after the session and auth middleware.
+func CSRF(h goji.Handler) goji.Handler {
+	fn := func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET", "HEAD", "OPTIONS", "TRACE":
+			h.ServeHTTPC(ctx, w, r)
+			return
+		}
+
+		if isJSON(w) && (auth.FromContext(ctx) == nil || auth.TokenFromContext(ctx) != nil) {
+			h.ServeHTTPC(ctx, w, r)
+			return
+		}
+
+		token := r.Header.Get(CSRFHeader)
+		if token == "" {
+			r.Body = http.MaxBytesReader(w, r.Body, conf.C.MaxBodySize)
+			if err := r.ParseForm(); err != nil {
+				if _, ok := err.(*http.MaxBytesError); ok {
+					writeStatus(ctx, w, http.StatusRequestEntityTooLarge, "request body too large")
+					return
+				}
+			}
+			token = r.PostFormValue(CSRFField)
+		}
+
+		if !validCSRFToken(session.FromContext(ctx), token) {
+			log.Printf("warning: invalid CSRF token request_id=%q method=%q url=%q",
+				GetRequestID(ctx), r.Method, r.URL.String())
+			writeStatus(ctx, w, http.StatusForbidden, "invalid or missing CSRF token")
+			return
+		}
+
+		h.ServeHTTPC(ctx, w, r)
+	}
+	return goji.HandlerFunc(fn)
+}
+
+// CSRFToken returns a CSRF token for the current session, which must be sent
+// with any unsafe request (see CSRF).  A different token is returned each
+// time, to protect against compression attacks such as BREACH, but all of
+// them are valid for the rest of the session.
+func CSRFToken(ctx context.Context) string {
+	sess := session.FromContext(ctx)
+
+	seed := sess.Get(csrfSessionKey)
+	if seed == "" {
+		seed = base64.RawURLEncoding.EncodeToString(randomBytes(csrfLength))
+		sess.Set(csrfSessionKey, seed)
+	}
+
+	// The token is a random mask, followed by the real token XOR'd with
+	// the mask.
+	mask := randomBytes(csrfLength)
+	masked := csrfMAC(conf.C.SessionKeys()[0], seed)
+	for i := range masked {
+		masked[i] ^= mask[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(append(mask, masked...))
+}
+
+// validCSRFToken returns whether the given token is valid for the session.
+func validCSRFToken(sess *session.Session, token string) bool {
+	seed := sess.Get(csrfSessionKey)
+	if seed == "" || token == "" {
+		return false
+	}
+
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) != 2*csrfLength {
+		return false
+	}
+	mask, unmasked := buf[:csrfLength], buf[csrfLength:]
+	for i := range unmasked {
+		unmasked[i] ^= mask[i]
+	}
+
+	for _, key := range conf.C.SessionKeys() {
+		if subtle.ConstantTimeCompare(unmasked, csrfMAC(key, seed)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func csrfMAC(key []byte, seed string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("csrf token\x00" + seed))
+	return mac.Sum(nil)
+}
+
+func randomBytes(n int) []byte {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return buf
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"goji.io"
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+	"github.com/andrew-d/go-webapp-skeleton/session"
+)
+
+// ok is a handler that responds with 200 OK.
+var ok = goji.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestCSRFToken(t *testing.T) {
+	conf.C = conf.Default()
+	conf.C.SessionSecret = strings.Repeat("s", 32)
+	defer func() { conf.C = nil }()
+
+	sess := session.New()
+	ctx := session.NewContext(context.Background(), sess)
+
+	// Tokens are masked differently each time, but all of them are valid.
+	a, b := CSRFToken(ctx), CSRFToken(ctx)
+	if a == b {
+		t.Errorf("CSRFToken returned the same token twice: %q", a)
+	}
+	for _, token := range []string{a, b} {
+		if !validCSRFToken(sess, token) {
+			t.Errorf("token %q is not valid for its session", token)
+		}
+	}
+
+	// Tokens stay valid after the secret is rotated, and stop being
+	// valid once the old secret is dropped.
+	conf.C.PreviousSessionSecrets = []string{conf.C.SessionSecret}
+	conf.C.SessionSecret = strings.Repeat("n", 32)
+	if !validCSRFToken(sess, a) {
+		t.Errorf("token is not valid after the secret was rotated")
+	}
+	conf.C.PreviousSessionSecrets = nil
+	if validCSRFToken(sess, a) {
+		t.Errorf("token is still valid after the old secret was dropped")
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	conf.C = conf.Default()
+	conf.C.SessionSecret = strings.Repeat("s", 32)
+	defer func() { conf.C = nil }()
+
+	sess := session.New()
+	ctx := session.NewContext(context.Background(), sess)
+	token := CSRFToken(ctx)
+	otherToken := CSRFToken(session.NewContext(context.Background(), session.New()))
+
+	user := &model.User{ID: 1}
+
+	// Change a character in the middle of the token (the last one may
+	// only hold padding bits).
+	i := len(token) / 2
+	tampered := token[:i] + "A" + token[i+1:]
+	if tampered == token {
+		tampered = token[:i] + "B" + token[i+1:]
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		header     string
+		form       string
+		api        bool
+		user       *model.User
+		bearer     bool
+		wantStatus int
+	}{
+		{name: "safe method", method: "GET", wantStatus: http.StatusOK},
+		{name: "header", method: "POST", header: token, wantStatus: http.StatusOK},
+		{name: "form field", method: "POST", form: token, wantStatus: http.StatusOK},
+		{name: "missing", method: "POST", wantStatus: http.StatusForbidden},
+		{name: "other session's token", method: "POST", header: otherToken, wantStatus: http.StatusForbidden},
+		{name: "tampered", method: "POST", header: tampered, wantStatus: http.StatusForbidden},
+		{name: "truncated", method: "POST", header: token[:len(token)-4], wantStatus: http.StatusForbidden},
+		{name: "not base64", method: "DELETE", header: "!!!", wantStatus: http.StatusForbidden},
+		{name: "too large", method: "POST", form: strings.Repeat("x", int(conf.C.MaxBodySize)), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "API without a user", method: "POST", api: true, wantStatus: http.StatusOK},
+		{name: "API with a token", method: "POST", api: true, user: user, bearer: true, wantStatus: http.StatusOK},
+		{name: "API with a session user", method: "POST", api: true, user: user, wantStatus: http.StatusForbidden},
+		{name: "API with a session user and token", method: "POST", api: true, user: user, header: token, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		var body string
+		if tt.form != "" {
+			body = url.Values{CSRFField: {tt.form}}.Encode()
+		}
+		r := httptest.NewRequest(tt.method, "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if tt.header != "" {
+			r.Header.Set(CSRFHeader, tt.header)
+		}
+
+		w := httptest.NewRecorder()
+		if tt.api {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		}
+
+		ctx := ctx
+		if tt.user != nil {
+			ctx = auth.NewContext(ctx, tt.user)
+		}
+		if tt.bearer {
+			ctx = auth.NewTokenContext(ctx, &model.Token{ID: 1, UserID: tt.user.ID})
+		}
+
+		CSRF(ok).ServeHTTPC(ctx, w, r)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+	}
+}
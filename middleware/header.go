@@ -1,29 +1,14 @@
 package middleware
 
-import (
-	"net/http"
-	"time"
-)
+import "net/http"
 
 func SetHeaders(h http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
-		// Allow XHR
-		w.Header().Add("Access-Control-Allow-Origin", "*")
-
 		// Security headers
 		w.Header().Add("X-Frame-Options", "DENY")
 		w.Header().Add("X-Content-Type-Options", "nosniff")
 		w.Header().Add("X-XSS-Protection", "1; mode=block")
 
-		// Disable all caching
-		w.Header().Add("Cache-Control", "no-cache")
-		w.Header().Add("Cache-Control", "no-store")
-		w.Header().Add("Cache-Control", "max-age=0")
-		w.Header().Add("Cache-Control", "must-revalidate")
-		w.Header().Add("Cache-Control", "value")
-		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
-		w.Header().Set("Expires", "Thu, 01 Jan 1970 00:00:00 GMT")
-
 		// HSTS for TLS connections.
 		if r.TLS != nil {
 			w.Header().Add("Strict-Transport-Security", "max-age=31536000")
@@ -33,3 +18,16 @@ func SetHeaders(h http.Handler) http.Handler {
 	}
 	return http.HandlerFunc(fn)
 }
+
+// NoCache stops responses from being cached, by browsers or proxies.  It
+// should be used for dynamic responses, but not for static assets, which set
+// their own caching headers.
+func NoCache(h http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store, no-cache, max-age=0, must-revalidate")
+		w.Header().Set("Expires", "Thu, 01 Jan 1970 00:00:00 GMT")
+
+		h.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+
+	"goji.io"
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/handler/apierror"
+)
+
+// RequirePermission returns a middleware that only allows requests from users
+// that have the given permission (see auth.Roles).  It can be used on both
+// API and frontend routes:
+//
+//     mux.HandleC(pat.Delete("/people/:person"),
+//         middleware.RequirePermission("people:delete")(goji.HandlerFunc(api.DeletePerson)))
+//
+// Other requests are rejected with a 403, or if there is no logged-in user, a
+// 401 (for API routes) or a redirect to the login page (for frontend routes).
+// API routes are recognized by their JSON content type (see JSON).
+func RequirePermission(perm string) func(goji.Handler) goji.Handler {
+	return func(h goji.Handler) goji.Handler {
+		fn := func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			user := auth.FromContext(ctx)
+			if user == nil {
+				if isJSON(w) {
+					unauthorized(ctx, w, "Bearer", "authentication required")
+				} else {
+					http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+				}
+				return
+			}
+
+			ok, err := auth.HasPermission(ctx, user, perm)
+			if err != nil {
+				log.Printf("error: could not check permission perm=%q user_id=%d err=%q", perm, user.ID, err)
+				writeStatus(ctx, w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			if !ok {
+				log.Printf("info: permission denied perm=%q user_id=%d", perm, user.ID)
+				writeStatus(ctx, w, http.StatusForbidden, "you do not have permission to do this")
+				return
+			}
+
+			h.ServeHTTPC(ctx, w, r)
+		}
+		return goji.HandlerFunc(fn)
+	}
+}
+
+// ErrorPage, if set, sends the error page for frontend routes (see
+// writeStatus).  If it is nil, a minimal HTML page is sent instead.
+var ErrorPage func(ctx context.Context, w http.ResponseWriter, status int, message string)
+
+// writeStatus sends an error with the given status and message, as a JSON
+// error for API routes and as an HTML page otherwise.
+func writeStatus(ctx context.Context, w http.ResponseWriter, status int, message string) {
+	if isJSON(w) {
+		writeJSONStatus(ctx, w, status, message)
+	} else {
+		writeHTMLStatus(ctx, w, status, message)
+	}
+}
+
+// writeJSONStatus sends an error with the given status and message as a JSON
+// error (see apierror).
+func writeJSONStatus(ctx context.Context, w http.ResponseWriter, status int, message string) {
+	e := apierror.New(status, message)
+	e.RequestID = GetRequestID(ctx)
+	apierror.Write(w, e)
+}
+
+// writeHTMLStatus sends an error with the given status and message as an
+// HTML page (see ErrorPage).
+func writeHTMLStatus(ctx context.Context, w http.ResponseWriter, status int, message string) {
+	if ErrorPage != nil {
+		ErrorPage(ctx, w, status, message)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<title>%d %s</title>\n<h1>%s</h1>\n<p>%s</p>\n",
+		status, http.StatusText(status), http.StatusText(status), html.EscapeString(message))
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+func TestRequirePermission(t *testing.T) {
+	editor := &model.User{ID: 1} // see testStore
+
+	tests := []struct {
+		name            string
+		perm            string
+		api             bool
+		user            *model.User
+		err             error
+		wantStatus      int
+		wantLocation    string
+		wantContentType string
+	}{
+		{
+			name:       "granted",
+			perm:       auth.PermPeopleWrite,
+			user:       editor,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:            "denied",
+			perm:            auth.PermPeopleDelete,
+			user:            editor,
+			wantStatus:      http.StatusForbidden,
+			wantContentType: "text/html",
+		},
+		{
+			name:            "denied on the API",
+			perm:            auth.PermPeopleDelete,
+			api:             true,
+			user:            editor,
+			wantStatus:      http.StatusForbidden,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "user without roles",
+			perm:            auth.PermPeopleWrite,
+			user:            &model.User{ID: 2},
+			wantStatus:      http.StatusForbidden,
+			wantContentType: "text/html",
+		},
+		{
+			name:         "not logged in",
+			perm:         auth.PermPeopleWrite,
+			wantStatus:   http.StatusSeeOther,
+			wantLocation: "/login?next=%2Fpeople%2F1%2Fedit%3Ftab%3Dname",
+		},
+		{
+			name:            "not logged in on the API",
+			perm:            auth.PermPeopleWrite,
+			api:             true,
+			wantStatus:      http.StatusUnauthorized,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "datastore error",
+			perm:            auth.PermPeopleWrite,
+			user:            editor,
+			err:             errors.New("broken"),
+			wantStatus:      http.StatusInternalServerError,
+			wantContentType: "text/html",
+		},
+	}
+
+	for _, tt := range tests {
+		ctx := datastore.NewContext(context.Background(), &testStore{err: tt.err})
+		if tt.user != nil {
+			ctx = auth.NewContext(ctx, tt.user)
+		}
+
+		r := httptest.NewRequest("GET", "/people/1/edit?tab=name", nil)
+		w := httptest.NewRecorder()
+		if tt.api {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		}
+		RequirePermission(tt.perm)(whoami).ServeHTTPC(ctx, w, r)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+		if got := w.Header().Get("Location"); got != tt.wantLocation {
+			t.Errorf("%s: Location = %q, want %q", tt.name, got, tt.wantLocation)
+		}
+		if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.wantContentType) {
+			t.Errorf("%s: Content-Type = %q, want %s", tt.name, got, tt.wantContentType)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"strings"
 
 	"goji.io"
 	"golang.org/x/net/context"
@@ -13,13 +14,24 @@ import (
 	"github.com/andrew-d/go-webapp-skeleton/conf"
 )
 
+// Recoverer recovers from panics in handlers, logs them, and sends an error
+// page.  API routes should use JSONRecoverer instead.
 func Recoverer(h goji.Handler) goji.Handler {
+	return recoverer(h, writeHTMLStatus)
+}
+
+// JSONRecoverer is like Recoverer, but sends a JSON error, for API routes.
+func JSONRecoverer(h goji.Handler) goji.Handler {
+	return recoverer(h, writeJSONStatus)
+}
+
+func recoverer(h goji.Handler, write func(context.Context, http.ResponseWriter, int, string)) goji.Handler {
 	f := func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 		id := GetRequestID(ctx)
 
 		defer func() {
 			if err := recover(); err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
+				write(ctx, w, http.StatusInternalServerError, "internal server error")
 
 				// Get the stack (from here, so we don't have
 				// an extraneous call)
@@ -36,6 +48,12 @@ func Recoverer(h goji.Handler) goji.Handler {
 	return goji.HandlerFunc(f)
 }
 
+// isJSON returns whether the response being written is JSON, which is the case
+// for API routes (see JSON).
+func isJSON(w http.ResponseWriter) bool {
+	return strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+}
+
 func handlePanic(requestId string, err interface{}, stack []byte) {
 	log.Printf(
 		"error: recovered from panic request_id=%q err=%q",
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"goji.io"
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+)
+
+func TestRecoverer(t *testing.T) {
+	conf.C = conf.Default()
+	defer func() { conf.C = nil }()
+
+	panics := goji.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		// The content type mustn't affect what kind of error is sent.
+		w.Header().Set("Content-Type", "application/json")
+		panic("oops")
+	})
+
+	tests := []struct {
+		name            string
+		middleware      func(goji.Handler) goji.Handler
+		wantContentType string
+	}{
+		{"Recoverer", Recoverer, "text/html"},
+		{"JSONRecoverer", JSONRecoverer, "application/json"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		tt.middleware(panics).ServeHTTPC(context.Background(), w, r)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, http.StatusInternalServerError)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.wantContentType) {
+			t.Errorf("%s: Content-Type = %q, want %s", tt.name, ct, tt.wantContentType)
+		}
+	}
+}
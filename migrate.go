@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+	"github.com/andrew-d/go-webapp-skeleton/datastore/database"
+	"github.com/andrew-d/go-webapp-skeleton/datastore/migrate"
+)
+
+const migrateUsage = `usage: %s [flags] migrate <command>
+
+Commands:
+    status    show all migrations and whether they have been applied
+    up        apply all pending migrations
+    down      revert the latest migration
+    to N      apply or revert migrations until the database is at version N
+    redo      revert and re-apply the latest migration
+`
+
+// runMigrate implements the 'migrate' subcommand, which manages the database
+// schema without starting the web server.  It returns the exit code.
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, migrateUsage, os.Args[0])
+		return 2
+	}
+
+	db, err := database.Open(conf.C.DbType, conf.C.DbConn)
+	if err != nil {
+		log.Printf("error: could not connect to database err=%q db_type=%q db_conn=%q",
+			err,
+			conf.C.DbType,
+			conf.C.DbConn)
+		return 1
+	}
+	defer db.Close()
+
+	migrator := database.NewMigrator(db)
+
+	switch {
+	case args[0] == "status" && len(args) == 1:
+		var statuses []*migrate.Status
+		statuses, err = migrator.Status(db)
+		if err != nil {
+			break
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			var name, status, appliedAt string
+			switch {
+			case s.Migration == nil:
+				name, status = s.Record.Name, "unknown"
+			case s.Changed():
+				name, status = s.Migration.Name, "changed"
+			case s.Applied():
+				name, status = s.Migration.Name, "applied"
+			default:
+				name, status = s.Migration.Name, "pending"
+			}
+			if s.Applied() {
+				appliedAt = s.Record.AppliedAt
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Version, name, status, appliedAt)
+		}
+		tw.Flush()
+
+	case args[0] == "up" && len(args) == 1:
+		err = migrator.Up(db)
+
+	case args[0] == "down" && len(args) == 1:
+		err = migrator.Down(db)
+
+	case args[0] == "redo" && len(args) == 1:
+		err = migrator.Redo(db)
+
+	case args[0] == "to" && len(args) == 2:
+		var version int
+		version, err = strconv.Atoi(args[1])
+		if err != nil {
+			err = fmt.Errorf("invalid version: %q", args[1])
+			break
+		}
+		err = migrator.To(db, version)
+
+	default:
+		fmt.Fprintf(os.Stderr, migrateUsage, os.Args[0])
+		return 2
+	}
+
+	if err != nil {
+		log.Printf("error: migration failed err=%q", err)
+		return 1
+	}
+	return 0
+}
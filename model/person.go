@@ -2,5 +2,5 @@ package model
 
 type Person struct {
 	ID   int64  `db:"id"   json:"id"`
-	Name string `db:"name" json:"name"`
+	Name string `db:"name" json:"name" validate:"trim,required,max=255"`
 }
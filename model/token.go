@@ -0,0 +1,16 @@
+package model
+
+// Token is a personal API token, which authenticates API requests as the
+// user that created it.
+type Token struct {
+	ID     int64  `db:"id"      json:"id"`
+	UserID int64  `db:"user_id" json:"-"`
+	Name   string `db:"name"    json:"name" validate:"trim,required,max=255"`
+
+	// Hash of the token itself, as created by auth.HashToken.  The token is
+	// only shown to the user once, when it is created.
+	Hash string `db:"token_hash" json:"-"`
+
+	// When the token was created, as a Unix timestamp.
+	CreatedAt int64 `db:"created_at" json:"created_at"`
+}
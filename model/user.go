@@ -0,0 +1,38 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/andrew-d/go-webapp-skeleton/validate"
+)
+
+type User struct {
+	ID    int64  `db:"id"    json:"id"`
+	Email string `db:"email" json:"email" validate:"trim,required,max=255"`
+
+	// Hash of the user's password, as created by auth.HashPassword.  This
+	// is never sent to the client.
+	PasswordHash string `db:"password_hash" json:"-"`
+}
+
+// Validate checks that the email address looks like one, and normalizes it
+// (see NormalizeEmail).
+func (u *User) Validate(errs *validate.Errors) {
+	u.Email = NormalizeEmail(u.Email)
+
+	// We only check the basic shape of the address; the only real way to
+	// check an address is to send an email to it.
+	if u.Email != "" {
+		at := strings.LastIndexByte(u.Email, '@')
+		if at < 1 || at == len(u.Email)-1 || strings.ContainsAny(u.Email, " \t\r\n") {
+			errs.Add("email", "must be a valid email address")
+		}
+	}
+}
+
+// NormalizeEmail returns the form of the given email address that is stored
+// in the database, so that addresses that differ only in case or surrounding
+// whitespace belong to the same user.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+	"github.com/andrew-d/go-webapp-skeleton/datastore"
+	"github.com/andrew-d/go-webapp-skeleton/datastore/database"
+	"github.com/andrew-d/go-webapp-skeleton/model"
+)
+
+const rolesUsage = `usage: %s [flags] roles <command>
+
+Commands:
+    list EMAIL           show the roles assigned to a user
+    grant EMAIL ROLE     assign a role to a user
+    revoke EMAIL ROLE    remove a role from a user
+
+Roles: %s
+`
+
+// runRoles implements the 'roles' subcommand, which manages the roles
+// assigned to users.  It returns the exit code.
+func runRoles(args []string) int {
+	usage := func() int {
+		fmt.Fprintf(os.Stderr, rolesUsage, os.Args[0], strings.Join(auth.RoleNames(), ", "))
+		return 2
+	}
+
+	if len(args) == 0 {
+		return usage()
+	}
+	switch {
+	case args[0] == "list" && len(args) == 2:
+	case (args[0] == "grant" || args[0] == "revoke") && len(args) == 3:
+	default:
+		return usage()
+	}
+
+	db, err := database.Connect(conf.C.DbType, conf.C.DbConn)
+	if err != nil {
+		log.Printf("error: could not connect to database err=%q db_type=%q db_conn=%q",
+			err,
+			conf.C.DbType,
+			conf.C.DbConn)
+		return 1
+	}
+	defer db.Close()
+
+	ctx := datastore.NewContext(context.Background(), database.NewDatastore(db))
+
+	user, err := datastore.GetUserByEmail(ctx, model.NormalizeEmail(args[1]))
+	if err != nil {
+		log.Printf("error: could not find user email=%q err=%q", args[1], err)
+		return 1
+	}
+
+	switch args[0] {
+	case "list":
+		var roles []string
+		roles, err = datastore.ListUserRoles(ctx, user.ID)
+		for _, role := range roles {
+			fmt.Println(role)
+		}
+
+	case "grant":
+		err = auth.GrantRole(ctx, user, args[2])
+
+	case "revoke":
+		err = auth.RevokeRole(ctx, user, args[2])
+	}
+
+	if err != nil {
+		log.Printf("error: %s failed err=%q", args[0], err)
+		return 1
+	}
+	return 0
+}
@@ -1,31 +1,49 @@
 package router
 
 import (
-	"fmt"
-	"net/http"
-
 	"goji.io"
 	"goji.io/pat"
 
+	"github.com/andrew-d/go-webapp-skeleton/auth"
+	"github.com/andrew-d/go-webapp-skeleton/handler"
 	"github.com/andrew-d/go-webapp-skeleton/handler/api"
 	"github.com/andrew-d/go-webapp-skeleton/handler/frontend"
+	"github.com/andrew-d/go-webapp-skeleton/middleware"
 )
 
-func API() *goji.Mux {
-	mux := goji.SubMux()
+// authed wraps a handler so that it can only be used by an authenticated
+// user (see middleware.RequireUser).
+func authed(h goji.HandlerFunc) goji.Handler {
+	return middleware.RequireUser(h)
+}
+
+// can wraps a handler so that it can only be used by a user with the given
+// permission (see middleware.RequirePermission).
+func can(perm string, h goji.HandlerFunc) goji.Handler {
+	return middleware.RequirePermission(perm)(h)
+}
+
+func API() *Routes {
+	mux := newRoutes(goji.SubMux())
 
 	// We pass the routes as relative to the point where the API router
 	// will be mounted.  The super-router will strip any prefix off for us.
 	mux.HandleFuncC(pat.Get("/people"), api.ListPeople)
-	mux.HandleFuncC(pat.Post("/people"), api.CreatePerson)
+	mux.HandleC(pat.Post("/people"), can(auth.PermPeopleWrite, api.CreatePerson))
 	mux.HandleFuncC(pat.Get("/people/:person"), api.GetPerson)
-	mux.HandleFuncC(pat.Delete("/people/:person"), api.DeletePerson)
+	mux.HandleC(pat.Put("/people/:person"), can(auth.PermPeopleWrite, api.UpdatePerson))
+	mux.HandleC(pat.Patch("/people/:person"), can(auth.PermPeopleWrite, api.PatchPerson))
+	mux.HandleC(pat.Delete("/people/:person"), can(auth.PermPeopleDelete, api.DeletePerson))
+
+	mux.HandleFuncC(pat.Post("/login"), api.Login)
+	mux.HandleFuncC(pat.Post("/logout"), api.Logout)
+
+	mux.HandleC(pat.Get("/tokens"), authed(api.ListTokens))
+	mux.HandleC(pat.Post("/tokens"), authed(api.CreateToken))
+	mux.HandleC(pat.Delete("/tokens/:token"), authed(api.DeleteToken))
 
 	// Add default 'not found' route that responds with JSON
-	mux.HandleFunc(pat.New("/*"), func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(404)
-		fmt.Fprint(w, `{"error":"not found"}`)
-	})
+	mux.HandleFuncC(pat.New("/*"), api.NotFound)
 
 	return mux
 }
@@ -33,8 +51,29 @@ func API() *goji.Mux {
 func Web() *goji.Mux {
 	mux := goji.SubMux()
 
-	mux.HandleFuncC(pat.Get("/people"), frontend.ListPeople)
-	mux.HandleFuncC(pat.Get("/people/:person"), frontend.GetPerson)
+	// Routes are named so that templates can link to them (see
+	// handler.URL).
+	mux.HandleFuncC(named("people", pat.Get("/people")), frontend.ListPeople)
+	mux.HandleC(named("person_new", pat.Get("/people/new")), can(auth.PermPeopleWrite, frontend.NewPerson))
+	mux.HandleC(pat.Post("/people"), can(auth.PermPeopleWrite, frontend.CreatePerson))
+	mux.HandleFuncC(named("person", pat.Get("/people/:person")), frontend.GetPerson)
+	mux.HandleC(named("person_edit", pat.Get("/people/:person/edit")), can(auth.PermPeopleWrite, frontend.EditPerson))
+	mux.HandleC(pat.Post("/people/:person/edit"), can(auth.PermPeopleWrite, frontend.UpdatePerson))
+	mux.HandleC(named("person_delete", pat.Get("/people/:person/delete")), can(auth.PermPeopleDelete, frontend.ConfirmDeletePerson))
+	mux.HandleC(pat.Post("/people/:person/delete"), can(auth.PermPeopleDelete, frontend.DeletePerson))
+
+	mux.HandleFuncC(named("register", pat.Get("/register")), frontend.ShowRegister)
+	mux.HandleFuncC(pat.Post("/register"), frontend.Register)
+	mux.HandleFuncC(named("login", pat.Get("/login")), frontend.ShowLogin)
+	mux.HandleFuncC(pat.Post("/login"), frontend.Login)
+	mux.HandleFuncC(named("logout", pat.Post("/logout")), frontend.Logout)
 
 	return mux
 }
+
+// named gives a name to the pattern's route (see handler.NameRoute), and
+// returns the pattern.
+func named(name string, p *pat.Pattern) *pat.Pattern {
+	handler.NameRoute(name, p.String())
+	return p
+}
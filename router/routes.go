@@ -0,0 +1,66 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+
+	"goji.io"
+	"goji.io/pat"
+	"golang.org/x/net/context"
+)
+
+// Routes is a mux that records the patterns registered on it, so that the
+// methods allowed for a path can be looked up (see Methods).
+type Routes struct {
+	*goji.Mux
+	patterns []*pat.Pattern
+}
+
+func newRoutes(mux *goji.Mux) *Routes {
+	return &Routes{Mux: mux}
+}
+
+// HandleC registers a handler, like goji.Mux.HandleC.
+func (rt *Routes) HandleC(p *pat.Pattern, h goji.Handler) {
+	rt.patterns = append(rt.patterns, p)
+	rt.Mux.HandleC(p, h)
+}
+
+// HandleFuncC registers a handler function, like goji.Mux.HandleFuncC.
+func (rt *Routes) HandleFuncC(p *pat.Pattern, h func(context.Context, http.ResponseWriter, *http.Request)) {
+	rt.HandleC(p, goji.HandlerFunc(h))
+}
+
+// Methods returns the HTTP methods accepted by the routes that match the
+// request's path, in alphabetical order, or nil if there are none.  Routes
+// that accept any method (such as the 'not found' route) are ignored.  It
+// must be called with the context that the mux's middleware receives.
+func (rt *Routes) Methods(ctx context.Context, r *http.Request) []string {
+	seen := make(map[string]bool)
+	for _, p := range rt.patterns {
+		methods := p.HTTPMethods()
+		if methods == nil {
+			continue
+		}
+
+		// The pattern checks the method before the path, so try one of
+		// the methods it accepts.
+		for method := range methods {
+			probe := *r
+			probe.Method = method
+			if p.Match(ctx, &probe) != nil {
+				for m := range methods {
+					seen[m] = true
+				}
+			}
+			break
+		}
+	}
+
+	var allowed []string
+	for m := range seen {
+		allowed = append(allowed, m)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
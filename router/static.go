@@ -0,0 +1,62 @@
+package router
+
+import (
+	"io/fs"
+	"log"
+	"time"
+
+	"goji.io"
+	"goji.io/pat"
+
+	"github.com/andrew-d/go-webapp-skeleton/handler/assets"
+	"github.com/andrew-d/go-webapp-skeleton/handler/frontend"
+)
+
+// Files that are served from the root of the site, as well as under
+// /static/, if they exist.
+var rootFiles = []string{
+	"clientaccesspolicy.xml",
+	"crossdomain.xml",
+	"favicon.ico",
+	"humans.txt",
+	"robots.txt",
+}
+
+// Static adds routes to mux for the static files in fsys: every file under
+// /static/, a few well-known files (such as robots.txt) at the root, and the
+// index page (index.html) at /, if there is one.  In single-page app mode
+// the index page is left to frontend.SPA instead.  The files under /static/
+// are compressed before this returns.  It returns the handler for /static/.
+func Static(mux *goji.Mux, fsys fs.FS, spa bool) *assets.Handler {
+	h := assets.New(fsys, "/static/")
+	h.NotFound = goji.HandlerFunc(frontend.NotFound)
+	mux.HandleC(pat.Get("/static/*"), h)
+
+	start := time.Now()
+	if err := h.Precompress(); err != nil {
+		log.Printf("warning: could not compress static files err=%q", err)
+	} else {
+		log.Printf("debug: compressed static files duration=%q", time.Since(start))
+	}
+
+	root := assets.New(fsys, "/")
+	root.NotFound = h.NotFound
+	for _, name := range rootFiles {
+		if _, err := fs.Stat(fsys, name); err == nil {
+			log.Printf("debug: adding special route for static file: %s", name)
+			mux.HandleC(pat.Get("/"+name), root)
+		}
+	}
+	if spa {
+		return h
+	}
+	for _, name := range root.Index {
+		if _, err := fs.Stat(fsys, name); err == nil {
+			log.Printf("debug: adding index route for static file: %s", name)
+			mux.HandleC(pat.Get("/"), root)
+			break
+		}
+	}
+
+	return h
+}
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCookie is returned when a cookie can't be decoded, because it was
+// tampered with, signed with an unknown key, or has expired.
+var ErrInvalidCookie = errors.New("session: invalid cookie")
+
+// codec signs, and optionally encrypts, cookie values.  Values are always
+// created with the first key, but any key is accepted when decoding, which
+// allows keys to be rotated.
+type codec struct {
+	keys    []derivedKeys
+	encrypt bool
+}
+
+type derivedKeys struct {
+	sign    []byte
+	encrypt cipher.AEAD
+}
+
+func newCodec(secrets [][]byte, encrypt bool) (*codec, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("session: no secrets given")
+	}
+
+	c := &codec{encrypt: encrypt}
+	for _, secret := range secrets {
+		// Use separate keys for signing and encryption, both derived from
+		// the secret.
+		block, err := aes.NewCipher(deriveKey(secret, "session encryption"))
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		c.keys = append(c.keys, derivedKeys{
+			sign:    deriveKey(secret, "session signing"),
+			encrypt: aead,
+		})
+	}
+	return c, nil
+}
+
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// Encode returns the cookie value for the given cookie name and value, which
+// expires at the given time.
+//
+// The value is: base64(payload) "." base64(HMAC(name "|" payload)), where the
+// payload is the expiry time followed by the (possibly encrypted) value.
+func (c *codec) Encode(name string, value []byte, expires time.Time) (string, error) {
+	key := c.keys[0]
+
+	payload := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(payload, uint64(expires.Unix()))
+
+	if c.encrypt {
+		nonce := make([]byte, key.encrypt.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		value = key.encrypt.Seal(nonce, nonce, value, []byte(name))
+	}
+	payload = append(payload, value...)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(sign(key.sign, name, payload)), nil
+}
+
+// Decode verifies and decodes a cookie value created by Encode.
+func (c *codec) Decode(name, cookie string) ([]byte, error) {
+	parts := strings.Split(cookie, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidCookie
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(payload) < 8 {
+		return nil, ErrInvalidCookie
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+
+	for _, key := range c.keys {
+		if !hmac.Equal(mac, sign(key.sign, name, payload)) {
+			continue
+		}
+
+		expires := time.Unix(int64(binary.BigEndian.Uint64(payload)), 0)
+		if time.Now().After(expires) {
+			return nil, ErrInvalidCookie
+		}
+
+		value := payload[8:]
+		if c.encrypt {
+			size := key.encrypt.NonceSize()
+			if len(value) < size {
+				return nil, ErrInvalidCookie
+			}
+			value, err = key.encrypt.Open(nil, value[:size], value[size:], []byte(name))
+			if err != nil {
+				return nil, ErrInvalidCookie
+			}
+		}
+		return value, nil
+	}
+
+	return nil, ErrInvalidCookie
+}
+
+func sign(key []byte, name string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name + "|"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
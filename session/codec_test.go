@@ -0,0 +1,185 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCodec(t *testing.T) {
+	secret := []byte(strings.Repeat("s", 32))
+	value := []byte(`{"user_id":"1"}`)
+	future := time.Now().Add(time.Hour)
+
+	// Each case changes a valid cookie, or makes its own.
+	tests := []struct {
+		name    string
+		cookie  func(c *codec) string
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			cookie: func(c *codec) string { return encode(t, c, "session", value, future) },
+		},
+		{
+			name:    "expired",
+			cookie:  func(c *codec) string { return encode(t, c, "session", value, time.Now().Add(-time.Second)) },
+			wantErr: true,
+		},
+		{
+			name:    "other cookie name",
+			cookie:  func(c *codec) string { return encode(t, c, "other", value, future) },
+			wantErr: true,
+		},
+		{
+			name: "tampered payload",
+			cookie: func(c *codec) string {
+				cookie := encode(t, c, "session", value, future)
+				return flip(cookie, 12)
+			},
+			wantErr: true,
+		},
+		{
+			name: "tampered signature",
+			cookie: func(c *codec) string {
+				cookie := encode(t, c, "session", value, future)
+				return flip(cookie, len(cookie)-2)
+			},
+			wantErr: true,
+		},
+		{
+			name: "extended expiry",
+			cookie: func(c *codec) string {
+				cookie := encode(t, c, "session", value, time.Now().Add(-time.Second))
+				later := encode(t, c, "session", value, future)
+				// Take the expiry from a later cookie, keeping
+				// the signature.
+				return later[:11] + cookie[11:]
+			},
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			cookie:  func(c *codec) string { return "" },
+			wantErr: true,
+		},
+		{
+			name:    "too many parts",
+			cookie:  func(c *codec) string { return encode(t, c, "session", value, future) + ".x" },
+			wantErr: true,
+		},
+		{
+			name:    "invalid base64",
+			cookie:  func(c *codec) string { return "!!!.!!!" },
+			wantErr: true,
+		},
+	}
+
+	for _, encrypt := range []bool{false, true} {
+		c, err := newCodec([][]byte{secret}, encrypt)
+		if err != nil {
+			t.Fatalf("newCodec: %s", err)
+		}
+
+		for _, tt := range tests {
+			cookie := tt.cookie(c)
+			got, err := c.Decode("session", cookie)
+			if tt.wantErr {
+				if err != ErrInvalidCookie {
+					t.Errorf("%s (encrypt=%t): Decode returned %q, %v, want ErrInvalidCookie", tt.name, encrypt, got, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s (encrypt=%t): Decode: %s", tt.name, encrypt, err)
+			} else if string(got) != string(value) {
+				t.Errorf("%s (encrypt=%t): Decode returned %q, want %q", tt.name, encrypt, got, value)
+			}
+		}
+	}
+}
+
+func TestCodecEncrypts(t *testing.T) {
+	c, err := newCodec([][]byte{[]byte(strings.Repeat("s", 32))}, true)
+	if err != nil {
+		t.Fatalf("newCodec: %s", err)
+	}
+
+	// Encrypted values use a random nonce, so the same value gives a
+	// different cookie each time.
+	a := encode(t, c, "session", []byte("secret value"), time.Now().Add(time.Hour))
+	b := encode(t, c, "session", []byte("secret value"), time.Now().Add(time.Hour))
+	if a == b {
+		t.Errorf("encoding the same value twice gave the same cookie %q", a)
+	}
+}
+
+func encode(t *testing.T, c *codec, name string, value []byte, expires time.Time) string {
+	t.Helper()
+	cookie, err := c.Encode(name, value, expires)
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+	return cookie
+}
+
+// flip changes the base64 character at index i of s to a different one.
+func flip(s string, i int) string {
+	c := byte('A')
+	if s[i] == 'A' {
+		c = 'B'
+	}
+	return s[:i] + string(c) + s[i+1:]
+}
+
+func TestCodecKeyRotation(t *testing.T) {
+	var (
+		oldSecret = []byte(strings.Repeat("o", 32))
+		newSecret = []byte(strings.Repeat("n", 32))
+		value     = []byte("value")
+		expires   = time.Now().Add(time.Hour)
+	)
+
+	for _, encrypt := range []bool{false, true} {
+		before, err := newCodec([][]byte{oldSecret}, encrypt)
+		if err != nil {
+			t.Fatalf("newCodec: %s", err)
+		}
+		rotated, err := newCodec([][]byte{newSecret, oldSecret}, encrypt)
+		if err != nil {
+			t.Fatalf("newCodec: %s", err)
+		}
+		dropped, err := newCodec([][]byte{newSecret}, encrypt)
+		if err != nil {
+			t.Fatalf("newCodec: %s", err)
+		}
+
+		oldCookie := encode(t, before, "session", value, expires)
+		newCookie := encode(t, rotated, "session", value, expires)
+
+		tests := []struct {
+			name    string
+			codec   *codec
+			cookie  string
+			wantErr bool
+		}{
+			{"old cookie with rotated keys", rotated, oldCookie, false},
+			{"old cookie after old key dropped", dropped, oldCookie, true},
+			{"new cookie with rotated keys", rotated, newCookie, false},
+			{"new cookie after old key dropped", dropped, newCookie, false},
+			{"new cookie with old key only", before, newCookie, true},
+		}
+		for _, tt := range tests {
+			got, err := tt.codec.Decode("session", tt.cookie)
+			if tt.wantErr {
+				if err != ErrInvalidCookie {
+					t.Errorf("%s (encrypt=%t): Decode returned %q, %v, want ErrInvalidCookie", tt.name, encrypt, got, err)
+				}
+			} else if err != nil {
+				t.Errorf("%s (encrypt=%t): Decode: %s", tt.name, encrypt, err)
+			} else if string(got) != string(value) {
+				t.Errorf("%s (encrypt=%t): Decode returned %q, want %q", tt.name, encrypt, got, value)
+			}
+		}
+	}
+}
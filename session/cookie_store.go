@@ -0,0 +1,73 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// maxCookieSize is the largest cookie that browsers are guaranteed to
+// accept.
+const maxCookieSize = 4096
+
+// ErrCookieTooLarge is returned by CookieStore.Save if the session has too
+// much data to fit in a cookie.
+var ErrCookieTooLarge = errors.New("session: cookie is too large")
+
+// CookieStore stores the entire session in a signed, and optionally
+// encrypted, cookie.
+type CookieStore struct {
+	opts  Options
+	codec *codec
+}
+
+// NewCookieStore creates a store that signs cookies with the given secrets
+// (see conf.Config.SessionKeys).  If encrypt is set, the session values are
+// also encrypted, so they can't be read by the client.
+func NewCookieStore(secrets [][]byte, encrypt bool, opts Options) (*CookieStore, error) {
+	c, err := newCodec(secrets, encrypt)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.setDefaults()
+	return &CookieStore{opts, c}, nil
+}
+
+func (s *CookieStore) Load(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(s.opts.Name)
+	if err != nil {
+		return New(), nil
+	}
+
+	data, err := s.codec.Decode(s.opts.Name, cookie.Value)
+	if err != nil {
+		return New(), nil
+	}
+
+	return unmarshal(data)
+}
+
+func (s *CookieStore) Save(w http.ResponseWriter, sess *Session) error {
+	if sess.destroyed {
+		http.SetCookie(w, s.opts.cookie("", time.Time{}))
+		return nil
+	}
+
+	data, err := sess.marshal()
+	if err != nil {
+		return err
+	}
+
+	expires := time.Now().Add(s.opts.MaxAge)
+	value, err := s.codec.Encode(s.opts.Name, data, expires)
+	if err != nil {
+		return err
+	}
+	if len(value) > maxCookieSize {
+		return ErrCookieTooLarge
+	}
+
+	http.SetCookie(w, s.opts.cookie(value, expires))
+	return nil
+}
@@ -0,0 +1,162 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DBStore stores sessions in the database, with only the (signed) session ID
+// stored in the cookie.  Unlike a CookieStore, sessions can be revoked on the
+// server and can hold any amount of data.
+type DBStore struct {
+	db    *sqlx.DB
+	opts  Options
+	codec *codec
+}
+
+// NewDBStore creates a store that keeps sessions in the 'sessions' table of
+// the given database, and signs the session ID cookie with the given secrets
+// (see conf.Config.SessionKeys).
+func NewDBStore(db *sqlx.DB, secrets [][]byte, opts Options) (*DBStore, error) {
+	c, err := newCodec(secrets, false)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.setDefaults()
+	return &DBStore{db, opts, c}, nil
+}
+
+func (s *DBStore) Load(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(s.opts.Name)
+	if err != nil {
+		return New(), nil
+	}
+
+	id, err := s.codec.Decode(s.opts.Name, cookie.Value)
+	if err != nil {
+		return New(), nil
+	}
+
+	row := struct {
+		Data      string `db:"data"`
+		ExpiresAt int64  `db:"expires_at"`
+	}{}
+	err = s.db.Get(&row, s.db.Rebind(sessionGetQuery), string(id))
+	if err != nil || time.Now().Unix() > row.ExpiresAt {
+		// Missing or expired sessions just start over.
+		return New(), nil
+	}
+
+	sess, err := unmarshal([]byte(row.Data))
+	if err != nil {
+		return nil, err
+	}
+	sess.id = string(id)
+	return sess, nil
+}
+
+func (s *DBStore) Save(w http.ResponseWriter, sess *Session) (err error) {
+	var tx *sqlx.Tx
+
+	tx, err = s.db.Beginx()
+	if err != nil {
+		return
+	}
+
+	// Automatically rollback/commit if there's an error.
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	// Remove the existing session (and the previous one, if the ID was
+	// regenerated), then insert the new data.
+	for _, id := range []string{sess.id, sess.oldID} {
+		if id == "" {
+			continue
+		}
+		if _, err = tx.Exec(s.db.Rebind(sessionDeleteQuery), id); err != nil {
+			return
+		}
+	}
+
+	if sess.destroyed {
+		http.SetCookie(w, s.opts.cookie("", time.Time{}))
+		return nil
+	}
+
+	if sess.id == "" {
+		if sess.id, err = newID(); err != nil {
+			return
+		}
+	}
+	sess.oldID = ""
+
+	var data []byte
+	if data, err = sess.marshal(); err != nil {
+		return
+	}
+
+	expires := time.Now().Add(s.opts.MaxAge)
+	_, err = tx.Exec(s.db.Rebind(sessionInsertQuery), sess.id, string(data), expires.Unix())
+	if err != nil {
+		return
+	}
+
+	var value string
+	if value, err = s.codec.Encode(s.opts.Name, []byte(sess.id), expires); err != nil {
+		return
+	}
+	http.SetCookie(w, s.opts.cookie(value, expires))
+	return nil
+}
+
+// DeleteExpired removes all expired sessions from the database.
+func (s *DBStore) DeleteExpired() error {
+	_, err := s.db.Exec(s.db.Rebind(sessionDeleteExpiredQuery), time.Now().Unix())
+	return err
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+const sessionGetQuery = `
+SELECT data, expires_at
+FROM sessions
+WHERE id = ?
+`
+
+const sessionInsertQuery = `
+INSERT
+INTO sessions (
+     id
+    ,data
+    ,expires_at
+)
+VALUES (?, ?, ?)
+`
+
+const sessionDeleteQuery = `
+DELETE
+FROM sessions
+WHERE id = ?
+`
+
+const sessionDeleteExpiredQuery = `
+DELETE
+FROM sessions
+WHERE expires_at < ?
+`
@@ -0,0 +1,69 @@
+package session
+
+import (
+	"log"
+	"net/http"
+
+	"goji.io"
+	"golang.org/x/net/context"
+)
+
+// Middleware returns a middleware that loads the session for each request
+// from the given store, puts it in the context, and saves it (if it was
+// changed) before the response is written.
+func Middleware(store Store) func(goji.Handler) goji.Handler {
+	return func(h goji.Handler) goji.Handler {
+		fn := func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			sess, err := store.Load(r)
+			if err != nil {
+				log.Printf("error: could not load session err=%q", err)
+				sess = New()
+			}
+
+			sw := &saveWriter{ResponseWriter: w, store: store, sess: sess}
+			h.ServeHTTPC(NewContext(ctx, sess), sw, r)
+			sw.save()
+		}
+		return goji.HandlerFunc(fn)
+	}
+}
+
+// saveWriter saves the session just before the response headers are
+// written, since the session cookie is one of those headers.
+type saveWriter struct {
+	http.ResponseWriter
+	store Store
+	sess  *Session
+	saved bool
+}
+
+func (w *saveWriter) save() {
+	if w.saved {
+		return
+	}
+	w.saved = true
+
+	if !w.sess.changed {
+		return
+	}
+	if err := w.store.Save(w.ResponseWriter, w.sess); err != nil {
+		log.Printf("error: could not save session err=%q", err)
+	}
+}
+
+func (w *saveWriter) WriteHeader(code int) {
+	w.save()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *saveWriter) Write(buf []byte) (int, error) {
+	w.save()
+	return w.ResponseWriter.Write(buf)
+}
+
+func (w *saveWriter) Flush() {
+	w.save()
+	if fl, ok := w.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
@@ -0,0 +1,159 @@
+// Package session provides cookie-based sessions, stored either entirely in a
+// signed (and optionally encrypted) cookie, or in the database with only the
+// session ID in the cookie.
+//
+// The session for the current request is available from the context once the
+// Middleware has run:
+//
+//     sess := session.FromContext(ctx)
+//     sess.Set("user_id", "123")
+//     sess.AddFlash(session.FlashSuccess, "Welcome back!")
+//
+package session
+
+import (
+	"encoding/json"
+
+	"golang.org/x/net/context"
+)
+
+// Kinds of flash messages.
+const (
+	FlashInfo    = "info"
+	FlashSuccess = "success"
+	FlashError   = "error"
+)
+
+// Flash is a message that is shown to the user once, on the next page they
+// view.
+type Flash struct {
+	Kind    string `json:"k"`
+	Message string `json:"m"`
+}
+
+// Session holds values that persist between requests from the same client.
+type Session struct {
+	values  map[string]string
+	flashes []Flash
+
+	// ID of the session, for stores that keep the data on the server.
+	id    string
+	oldID string
+
+	isNew     bool
+	changed   bool
+	destroyed bool
+}
+
+// New creates a new, empty session.
+func New() *Session {
+	return &Session{
+		values: make(map[string]string),
+		isNew:  true,
+	}
+}
+
+// IsNew returns whether the session was created during this request.
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// Get returns the value for the given key, or the empty string.
+func (s *Session) Get(key string) string {
+	return s.values[key]
+}
+
+// Set sets the value for the given key.
+func (s *Session) Set(key, value string) {
+	s.values[key] = value
+	s.changed = true
+}
+
+// Delete removes the value for the given key.
+func (s *Session) Delete(key string) {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.changed = true
+	}
+}
+
+// AddFlash adds a flash message, which will be kept until it is removed by a
+// call to ClearFlashes.
+func (s *Session) AddFlash(kind, message string) {
+	s.flashes = append(s.flashes, Flash{kind, message})
+	s.changed = true
+}
+
+// Flashes returns all flash messages.
+func (s *Session) Flashes() []Flash {
+	return s.flashes
+}
+
+// ClearFlashes removes all flash messages from the session, once they have
+// been shown to the user.
+func (s *Session) ClearFlashes() {
+	if len(s.flashes) > 0 {
+		s.flashes = nil
+		s.changed = true
+	}
+}
+
+// Regenerate gives the session a new ID, keeping its values.  This should be
+// done whenever the privilege level of the session changes (e.g. on login),
+// to prevent session fixation.
+func (s *Session) Regenerate() {
+	if s.oldID == "" {
+		s.oldID = s.id
+	}
+	s.id = ""
+	s.changed = true
+}
+
+// Destroy removes all values from the session, and removes the session from
+// the client.
+func (s *Session) Destroy() {
+	s.values = make(map[string]string)
+	s.flashes = nil
+	s.destroyed = true
+	s.changed = true
+}
+
+// data is the serialized form of a session.
+type data struct {
+	Values  map[string]string `json:"v,omitempty"`
+	Flashes []Flash           `json:"f,omitempty"`
+}
+
+func (s *Session) marshal() ([]byte, error) {
+	return json.Marshal(data{s.values, s.flashes})
+}
+
+func unmarshal(buf []byte) (*Session, error) {
+	var d data
+	if err := json.Unmarshal(buf, &d); err != nil {
+		return nil, err
+	}
+
+	s := &Session{values: d.Values, flashes: d.Flashes}
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+	return s, nil
+}
+
+type private struct{}
+
+var contextKey private
+
+func NewContext(parent context.Context, s *Session) context.Context {
+	return context.WithValue(parent, contextKey, s)
+}
+
+// FromContext returns the session for the current request.  If there is no
+// session in the context, an empty session that is never saved is returned.
+func FromContext(c context.Context) *Session {
+	if s, ok := c.Value(contextKey).(*Session); ok {
+		return s
+	}
+	return New()
+}
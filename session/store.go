@@ -0,0 +1,80 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/andrew-d/go-webapp-skeleton/conf"
+)
+
+// Store loads and saves sessions.
+type Store interface {
+	// Load returns the session for the given request, or a new session if
+	// the request doesn't have a valid one.
+	Load(r *http.Request) (*Session, error)
+
+	// Save saves the session, and sets the session cookie on the response.
+	// It must be called before the response headers are written.
+	Save(w http.ResponseWriter, s *Session) error
+}
+
+// Options controls the session cookie.
+type Options struct {
+	// Name of the cookie.  Defaults to "session".
+	Name string
+
+	// How long sessions last.  Defaults to 30 days.
+	MaxAge time.Duration
+
+	// Whether to only send the cookie over HTTPS.
+	Secure bool
+}
+
+func (o *Options) setDefaults() {
+	if o.Name == "" {
+		o.Name = "session"
+	}
+	if o.MaxAge == 0 {
+		o.MaxAge = 30 * 24 * time.Hour
+	}
+}
+
+// cookie returns the session cookie with the given value.  An empty value
+// returns a cookie that deletes the session cookie.
+func (o *Options) cookie(value string, expires time.Time) *http.Cookie {
+	c := &http.Cookie{
+		Name:     o.Name,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		MaxAge:   int(o.MaxAge / time.Second),
+		Secure:   o.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if value == "" {
+		c.Expires = time.Unix(0, 0)
+		c.MaxAge = -1
+	}
+	return c
+}
+
+// NewStore creates the session store selected by the given configuration.
+func NewStore(c *conf.Config, db *sqlx.DB) (Store, error) {
+	opts := Options{
+		Name:   conf.ProjectName + "_session",
+		Secure: c.IsProduction(),
+	}
+
+	switch c.SessionStore {
+	case "cookie":
+		return NewCookieStore(c.SessionKeys(), c.SessionEncrypt, opts)
+	case "database":
+		return NewDBStore(db, c.SessionKeys(), opts)
+	default:
+		return nil, fmt.Errorf("unknown session store %q", c.SessionStore)
+	}
+}
@@ -0,0 +1,100 @@
+package static
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+)
+
+// FS returns the embedded assets as a file system.
+func FS() fs.FS {
+	return bindataFS{}
+}
+
+type bindataFS struct{}
+
+func (bindataFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if data, err := Asset(name); err == nil {
+		info, err := AssetInfo(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &file{Reader: bytes.NewReader(data), info: fileInfo{info}}, nil
+	}
+
+	dir := name
+	if dir == "." {
+		dir = ""
+	}
+	if children, err := AssetDir(dir); err == nil {
+		return &dirFile{name: name, children: children}, nil
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// file is an open asset.
+type file struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+// fileInfo gives the base name of an asset, where go-bindata gives the full
+// name.
+type fileInfo struct {
+	os.FileInfo
+}
+
+func (fi fileInfo) Name() string { return path.Base(fi.FileInfo.Name()) }
+
+// dirFile is an open directory of assets.
+type dirFile struct {
+	name     string
+	children []string
+	offset   int
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return dirInfo(path.Base(d.name)), nil }
+func (d *dirFile) Close() error               { return nil }
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	for ; d.offset < len(d.children) && (n <= 0 || len(entries) < n); d.offset++ {
+		name := d.children[d.offset]
+		if d.name != "." {
+			name = d.name + "/" + name
+		}
+		info, err := fs.Stat(bindataFS{}, name)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	if n > 0 && len(entries) == 0 {
+		return nil, io.EOF
+	}
+	return entries, nil
+}
+
+type dirInfo string
+
+func (d dirInfo) Name() string       { return string(d) }
+func (d dirInfo) Size() int64        { return 0 }
+func (d dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (d dirInfo) ModTime() time.Time { return time.Time{} }
+func (d dirInfo) IsDir() bool        { return true }
+func (d dirInfo) Sys() interface{}   { return nil }
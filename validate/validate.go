@@ -0,0 +1,163 @@
+// Package validate checks (and cleans up) models before they are saved.
+//
+// Validation rules are given in a "validate" struct tag on each string field,
+// as a comma-separated list:
+//
+//     trim      remove leading and trailing whitespace
+//     required  the field must not be empty
+//     min=N     the field must be at least N characters long
+//     max=N     the field must be at most N characters long
+//
+// Every string field must also be valid UTF-8 and must not contain control
+// characters, and is normalised to NFC before the rules are applied, so that
+// visually identical strings are stored (and compared) the same way.  Models
+// can perform additional checks by implementing the Validator interface.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// FieldError describes a problem with a single field of a model.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Errors is a list of problems found when validating a model.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Add appends a new error for the given field.
+func (e *Errors) Add(field, format string, args ...interface{}) {
+	*e = append(*e, &FieldError{field, fmt.Sprintf(format, args...)})
+}
+
+// Validator is implemented by models that have checks beyond what can be
+// expressed in struct tags.  Validate is called after the struct tags have
+// been checked, and should add any problems to errs.
+type Validator interface {
+	Validate(errs *Errors)
+}
+
+// Struct validates the struct pointed to by v according to its struct tags,
+// and then calls its Validate method if it implements Validator.  Fields may
+// be modified (e.g. trimmed).  All problems are returned at once, as Errors.
+func Struct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panic("validate: Struct requires a pointer to a struct")
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	var errs Errors
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" || sf.Type.Kind() != reflect.String {
+			continue
+		}
+
+		name := fieldName(sf)
+		fv := rv.Field(i)
+
+		s := fv.String()
+		if !utf8.ValidString(s) {
+			errs.Add(name, "must be valid UTF-8")
+			continue
+		}
+		if strings.IndexFunc(s, isControl) >= 0 {
+			errs.Add(name, "must not contain control characters")
+			continue
+		}
+		s = norm.NFC.String(s)
+
+		for _, rule := range strings.Split(sf.Tag.Get("validate"), ",") {
+			if err := checkRule(rule, &s); err != "" {
+				errs.Add(name, "%s", err)
+				break
+			}
+		}
+		fv.SetString(s)
+	}
+
+	if vv, ok := v.(Validator); ok {
+		vv.Validate(&errs)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkRule applies a single rule to the given string, returning an error
+// message if the rule fails.
+func checkRule(rule string, s *string) string {
+	var arg string
+	if i := strings.IndexByte(rule, '='); i >= 0 {
+		rule, arg = rule[:i], rule[i+1:]
+	}
+
+	switch rule {
+	case "":
+	case "trim":
+		*s = strings.TrimSpace(*s)
+	case "required":
+		if *s == "" {
+			return "is required"
+		}
+	case "min":
+		if n := mustAtoi(arg); utf8.RuneCountInString(*s) < n {
+			return fmt.Sprintf("must be at least %d characters long", n)
+		}
+	case "max":
+		if n := mustAtoi(arg); utf8.RuneCountInString(*s) > n {
+			return fmt.Sprintf("must be at most %d characters long", n)
+		}
+	default:
+		panic("validate: unknown rule " + strconv.Quote(rule))
+	}
+	return ""
+}
+
+// fieldName returns the name of the field as seen by the client, which is
+// the name from the JSON tag if there is one.
+func fieldName(sf reflect.StructField) string {
+	if name := strings.Split(sf.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return sf.Name
+}
+
+// isControl reports whether r is a control character other than ordinary
+// whitespace.
+func isControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r'
+}
+
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic("validate: invalid rule argument " + strconv.Quote(s))
+	}
+	return n
+}
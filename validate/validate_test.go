@@ -0,0 +1,132 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testModel struct {
+	Name     string `json:"name" validate:"trim,required,min=2,max=5"`
+	Optional string `validate:"trim,max=3"`
+	Ignored  int    `validate:"required"`
+	private  string
+}
+
+// Validate rejects one particular name, to check that it is called after the
+// struct tags.
+func (m *testModel) Validate(errs *Errors) {
+	if m.Name == "admin" {
+		errs.Add("name", "is reserved")
+	}
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    testModel
+		want     testModel
+		wantErrs []string
+	}{
+		{
+			name:  "valid",
+			model: testModel{Name: "Alice"},
+			want:  testModel{Name: "Alice"},
+		},
+		{
+			name:  "trimmed",
+			model: testModel{Name: "  Bob\n", Optional: " x "},
+			want:  testModel{Name: "Bob", Optional: "x"},
+		},
+		{
+			name:  "normalised to NFC",
+			model: testModel{Name: "Zoe\u0301"},
+			want:  testModel{Name: "Zo\u00e9"},
+		},
+		{
+			name:  "length counts characters, not bytes",
+			model: testModel{Name: "\u00e9\u00e9\u00e9\u00e9"},
+			want:  testModel{Name: "\u00e9\u00e9\u00e9\u00e9"},
+		},
+		{
+			name:     "required",
+			model:    testModel{Name: "   "},
+			want:     testModel{Name: ""},
+			wantErrs: []string{"name: is required"},
+		},
+		{
+			name:     "too short",
+			model:    testModel{Name: "A"},
+			want:     testModel{Name: "A"},
+			wantErrs: []string{"name: must be at least 2 characters long"},
+		},
+		{
+			name:     "too long",
+			model:    testModel{Name: "Alexander", Optional: "abcd"},
+			want:     testModel{Name: "Alexander", Optional: "abcd"},
+			wantErrs: []string{"name: must be at most 5 characters long", "Optional: must be at most 3 characters long"},
+		},
+		{
+			name:     "invalid UTF-8",
+			model:    testModel{Name: "Al\xffce"},
+			want:     testModel{Name: "Al\xffce"},
+			wantErrs: []string{"name: must be valid UTF-8"},
+		},
+		{
+			name:     "control characters",
+			model:    testModel{Name: "Al\x00ce"},
+			want:     testModel{Name: "Al\x00ce"},
+			wantErrs: []string{"name: must not contain control characters"},
+		},
+		{
+			name:     "Validate method",
+			model:    testModel{Name: "admin"},
+			want:     testModel{Name: "admin"},
+			wantErrs: []string{"name: is reserved"},
+		},
+	}
+
+	for _, tt := range tests {
+		m := tt.model
+		err := Struct(&m)
+
+		var gotErrs []string
+		if err != nil {
+			for _, fe := range err.(Errors) {
+				gotErrs = append(gotErrs, fe.Error())
+			}
+		}
+		if !reflect.DeepEqual(gotErrs, tt.wantErrs) {
+			t.Errorf("%s: errors = %q, want %q", tt.name, gotErrs, tt.wantErrs)
+		}
+		if m != tt.want {
+			t.Errorf("%s: model = %+v, want %+v", tt.name, m, tt.want)
+		}
+	}
+}
+
+func TestStructPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"not a pointer", testModel{}},
+		{"not a struct", new(string)},
+		{"unknown rule", &struct {
+			Name string `validate:"bogus"`
+		}{}},
+		{"invalid argument", &struct {
+			Name string `validate:"max=five"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: Struct didn't panic", tt.name)
+				}
+			}()
+			Struct(tt.v)
+		}()
+	}
+}